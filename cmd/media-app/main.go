@@ -0,0 +1,34 @@
+// media-app 无界面的命令行工具，与桌面应用共用配置目录与媒体索引
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"media-app/internal/cli"
+	"media-app/internal/handler"
+	"media-app/pkg/file"
+	"media-app/pkg/logger"
+)
+
+func main() {
+	cfg := logger.DefaultConfig()
+	cfg.Env = logger.Prod
+	cfg.Level = "info"
+	cfg.FileName = filepath.Join(handler.ConfigDir(), "logs", "cli.log")
+	cfg.OutputConsole = false
+	if err := logger.Init(cfg); err != nil {
+		panic("初始化日志失败: " + err.Error())
+	}
+	defer logger.Sync()
+	file.SetJournalDir(filepath.Join(handler.ConfigDir(), "journals"))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	code := cli.Run(ctx, os.Args[1:], os.Stdout, os.Stderr)
+	stop()
+	logger.Sync()
+	os.Exit(code)
+}
@@ -4,3 +4,11 @@ export { default as EmptyState } from './EmptyState.vue'
 export { default as SimilarGroups } from './SimilarGroups.vue'
 export { default as ClassifyViewer } from './ClassifyViewer.vue'
 export { default as ShortcutSettings } from './ShortcutSettings.vue'
+export { default as SortSelect } from './SortSelect.vue'
+export { default as SearchBox } from './SearchBox.vue'
+export { default as MediaDetailPanel } from './MediaDetailPanel.vue'
+export { default as ExportProgress } from './ExportProgress.vue'
+export { default as RenamePreview } from './RenamePreview.vue'
+export { default as RenameRecovery } from './RenameRecovery.vue'
+export { default as BatchRenameProgress } from './BatchRenameProgress.vue'
+export { default as BatchRenameDialog } from './BatchRenameDialog.vue'
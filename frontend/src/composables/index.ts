@@ -4,3 +4,11 @@ export {useSelectedDir} from './useSelectedDir'
 export {useSimilarImages} from './useSimilarImages'
 export {useShortcuts} from './useShortcuts'
 export {useClassifyViewer} from './useClassifyViewer'
+export {useMediaSort} from './useMediaSort'
+export {useMediaQuery} from './useMediaQuery'
+export {useMediaDetail} from './useMediaDetail'
+export {useServerStatus} from './useServerStatus'
+export {useExport} from './useExport'
+export {useRenamePlan} from './useRenamePlan'
+export {useRenameRecovery} from './useRenameRecovery'
+export {useBatchRename} from './useBatchRename'
@@ -0,0 +1,88 @@
+import {computed, ref} from "vue";
+import {EventsOn} from "../../wailsjs/runtime";
+import {CancelBatchRename, GetBatchRename, StartBatchRename} from "../../wailsjs/go/app/App";
+import {file, handler} from "../../wailsjs/go/models";
+
+// 全局状态，跟踪最近一次批量重命名，任务由批量设置对话框或 REST API 启动
+const current = ref<handler.BatchJob | null>(null);
+const isSetupOpen = ref(false);
+const setupError = ref("");
+// 文件夹选择方式，关闭对话框后保留
+const dirOptions = ref(new file.BatchDirOptions({recursive: false, maxDepth: 0, include: [], exclude: []}));
+
+EventsOn("batch-rename-progress", (job: handler.BatchJob) => {
+  current.value = job;
+});
+
+EventsOn("batch-rename-setup", () => {
+  setupError.value = "";
+  isSetupOpen.value = true;
+});
+
+/**
+ * 批量重命名进度 composable，进度通过 batch-rename-progress 事件更新
+ */
+export function useBatchRename() {
+  const isRunning = computed(() => current.value?.state === "running");
+  const percent = computed(() => {
+    const progress = current.value?.progress;
+    if (!progress || progress.total === 0) return 0;
+    return Math.round((progress.done / progress.total) * 100);
+  });
+  const failures = computed(() => current.value?.summary?.results.filter(result => result.error) ?? []);
+
+  /**
+   * 按重命名选项与文件夹选择方式开始批量重命名
+   */
+  async function start(options: file.RenameOptions) {
+    setupError.value = "";
+    try {
+      current.value = await StartBatchRename(handler.BatchRenameRequest.createFrom({...options, ...dirOptions.value}));
+      isSetupOpen.value = false;
+    } catch (err) {
+      setupError.value = String(err);
+    }
+  }
+
+  /**
+   * 加载执行中的任务，前端刷新后恢复进度显示
+   */
+  async function load() {
+    const job = await GetBatchRename();
+    if (job.id && job.state === "running") {
+      current.value = job;
+    }
+  }
+
+  /**
+   * 取消批量重命名，正在处理的文件夹会执行完成
+   */
+  async function cancel() {
+    if (current.value && isRunning.value) {
+      await CancelBatchRename(current.value.id);
+    }
+  }
+
+  /**
+   * 关闭已结束的任务结果
+   */
+  function dismiss() {
+    if (!isRunning.value) {
+      current.value = null;
+    }
+  }
+
+  return {
+    current,
+    isRunning,
+    percent,
+    failures,
+    isSetupOpen,
+    setupError,
+    dirOptions,
+    start,
+    load,
+    cancel,
+    dismiss,
+  };
+}
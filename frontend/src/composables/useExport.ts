@@ -0,0 +1,90 @@
+import {computed, ref} from "vue";
+import {EventsOn} from "../../wailsjs/runtime";
+import {CancelExport, ExportCategory, ExportZip} from "../../wailsjs/go/app/App";
+import {handler} from "../../wailsjs/go/models";
+import type {ExportRequest, ExportStatus} from "@/types";
+
+// 全局状态，同一时间只跟踪最近一次导出
+const current = ref<ExportStatus | null>(null);
+const currentName = ref("");
+
+EventsOn("export-progress", (status: ExportStatus) => {
+  if (current.value?.id === status.id) {
+    current.value = status;
+  }
+});
+
+/**
+ * ZIP 导出 composable，导出在后台进行，进度通过 export-progress 事件更新
+ */
+export function useExport() {
+  const isExporting = computed(() => !!current.value && !current.value.done);
+  const percent = computed(() => {
+    const status = current.value;
+    if (!status || status.totalBytes === 0) return 0;
+    return Math.round((status.bytes / status.totalBytes) * 100);
+  });
+
+  /**
+   * 开始跟踪新的导出任务，用户取消选择保存位置时 info.id 为空
+   */
+  function track(info: handler.ExportInfo) {
+    if (!info.id) return;
+    currentName.value = info.name;
+    current.value = {
+      id: info.id, files: 0, totalFiles: info.files, bytes: 0, totalBytes: 0,
+      current: "", done: false, canceled: false,
+    };
+  }
+
+  /**
+   * 导出选中的文件
+   */
+  async function exportZip(request: ExportRequest) {
+    try {
+      track(await ExportZip(handler.ExportRequest.createFrom({paths: [], dir: "", ...request})));
+    } catch (error) {
+      console.error("导出失败:", error);
+    }
+  }
+
+  /**
+   * 导出快捷键对应分类文件夹中的媒体
+   */
+  async function exportCategory(shortcutKey: string) {
+    try {
+      track(await ExportCategory(shortcutKey));
+    } catch (error) {
+      console.error("导出失败:", error);
+    }
+  }
+
+  /**
+   * 取消当前导出
+   */
+  async function cancel() {
+    if (current.value && !current.value.done) {
+      await CancelExport(current.value.id);
+    }
+  }
+
+  /**
+   * 关闭已结束的导出提示
+   */
+  function dismiss() {
+    if (current.value?.done) {
+      current.value = null;
+    }
+  }
+
+  return {
+    current,
+    currentName,
+    isExporting,
+    percent,
+    exportZip,
+    exportCategory,
+    cancel,
+    dismiss,
+  };
+}
@@ -0,0 +1,35 @@
+import {ref, watch, type Ref} from "vue";
+import {GetMediaDetail} from "../../wailsjs/go/app/App";
+import type {MediaInfo} from "@/types";
+
+/**
+ * 媒体详情 composable，media 变化时从后端读取包含 EXIF 的详情
+ */
+export function useMediaDetail(media: Ref<MediaInfo | null>) {
+  const detail = ref<MediaInfo | null>(null);
+  const loading = ref(false);
+
+  watch(media, async (current) => {
+    detail.value = current;
+    if (!current) {
+      return;
+    }
+    loading.value = true;
+    try {
+      const result = (await GetMediaDetail(current.path)) as unknown as MediaInfo;
+      // 请求期间已切换到其他媒体时丢弃结果
+      if (media.value?.path === current.path) {
+        detail.value = result;
+      }
+    } catch (error) {
+      console.error("读取媒体详情失败:", error);
+    } finally {
+      loading.value = false;
+    }
+  }, {immediate: true});
+
+  return {
+    detail,
+    loading,
+  };
+}
@@ -1,14 +1,65 @@
 import {ref} from "vue";
 import {EventsOn} from "../../wailsjs/runtime";
-import type {MediaInfo} from "@/types";
+import {GetMediaPage} from "../../wailsjs/go/app/App";
+import type {MediaChunk, MediaInfo} from "@/types";
+
+// 分页拉取的每页条数
+const PAGE_SIZE = 1000;
 
 // 全局状态，在模块加载时就创建
 const mediaList = ref<MediaInfo[]>([]);
 const mediaCount = ref(0);
+const isScanning = ref(false);
+let currentScanId = 0;
+
+/**
+ * 扫描完成后按排序结果分页拉取完整列表
+ */
+async function loadSortedPages(scanId: number, total: number) {
+  const sorted: MediaInfo[] = [];
+  for (let offset = 0; offset < total; offset += PAGE_SIZE) {
+    const page = await GetMediaPage(offset, PAGE_SIZE);
+    if (scanId !== currentScanId) return;
+    sorted.push(...(page.items as MediaInfo[]));
+  }
+  mediaList.value = sorted;
+}
 
 // 在模块加载时就注册事件监听，确保不会错过事件
-EventsOn("media-list", (data: MediaInfo[]) => {
-  mediaList.value = data;
+EventsOn("media-list-chunk", (chunk: MediaChunk) => {
+  if (chunk.scanId < currentScanId) return;
+  if (chunk.scanId > currentScanId) {
+    currentScanId = chunk.scanId;
+    mediaList.value = [];
+  }
+  if (chunk.done) {
+    isScanning.value = false;
+    loadSortedPages(chunk.scanId, chunk.total).catch((error) => {
+      console.error("加载媒体列表失败:", error);
+    });
+    return;
+  }
+  isScanning.value = true;
+  mediaList.value = mediaList.value.concat(chunk.items);
+});
+
+// 目录在外部发生变化时增量更新
+EventsOn("media-removed", (removed: MediaInfo[]) => {
+  const paths = new Set(removed.map((media) => media.path));
+  mediaList.value = mediaList.value.filter((media) => !paths.has(media.path));
+});
+
+EventsOn("media-changed", (changed: MediaInfo[]) => {
+  const updates = new Map(changed.map((media) => [media.path, media]));
+  mediaList.value = mediaList.value.map((media) => updates.get(media.path) ?? media);
+});
+
+// 新增的文件需要按当前排序插入，直接重新拉取排序后的列表
+EventsOn("media-added", (added: MediaInfo[]) => {
+  if (isScanning.value) return;
+  loadSortedPages(currentScanId, mediaList.value.length + added.length).catch((error) => {
+    console.error("加载媒体列表失败:", error);
+  });
 });
 
 EventsOn("media-count", (data: number) => {
@@ -16,11 +67,12 @@ EventsOn("media-count", (data: number) => {
 });
 
 /**
- * 媒体列表管理 composable 监听 media-list 事件，管理媒体列表状态
+ * 媒体列表管理 composable 监听 media-list-chunk 事件，管理媒体列表状态
  */
 export function useMediaList() {
   return {
     mediaList,
-    mediaCount
+    mediaCount,
+    isScanning
   };
 }
@@ -0,0 +1,90 @@
+import {computed, ref} from "vue";
+import {EventsOn} from "../../wailsjs/runtime";
+import {QueryMedia} from "../../wailsjs/go/app/App";
+import {handler} from "../../wailsjs/go/models";
+import type {MediaChunk, MediaInfo, MediaQuery, MediaType} from "@/types";
+
+// 分页拉取的每页条数
+const PAGE_SIZE = 1000;
+
+// 全局状态，在模块加载时就创建
+const keyword = ref("");
+const mediaType = ref<MediaType | "">("");
+const results = ref<MediaInfo[]>([]);
+const queryError = ref("");
+let querySeq = 0;
+
+const isActive = computed(() => keyword.value.trim() !== "" || mediaType.value !== "");
+
+/**
+ * 按当前条件查询，结果与当前排序一致
+ */
+async function runQuery() {
+  const seq = ++querySeq;
+  if (!isActive.value) {
+    results.value = [];
+    queryError.value = "";
+    return;
+  }
+  const query: MediaQuery = {name: keyword.value.trim()};
+  if (mediaType.value) {
+    query.types = [mediaType.value];
+  }
+  try {
+    const matches: MediaInfo[] = [];
+    let total = 0;
+    let offset = 0;
+    do {
+      const page = await QueryMedia(handler.MediaQuery.createFrom(query), offset, PAGE_SIZE);
+      if (seq !== querySeq) return;
+      matches.push(...(page.items as MediaInfo[]));
+      total = page.total;
+      offset += PAGE_SIZE;
+    } while (offset < total);
+    results.value = matches;
+    queryError.value = "";
+  } catch (error) {
+    if (seq !== querySeq) return;
+    results.value = [];
+    queryError.value = String(error);
+  }
+}
+
+// 列表重新排序或扫描完成后刷新查询结果
+EventsOn("media-list-chunk", (chunk: MediaChunk) => {
+  if (chunk.done && isActive.value) {
+    runQuery();
+  }
+});
+
+/**
+ * 媒体过滤与搜索 composable
+ */
+export function useMediaQuery() {
+
+  /**
+   * 修改搜索条件
+   */
+  function search(name: string, type: MediaType | "") {
+    keyword.value = name;
+    mediaType.value = type;
+    runQuery();
+  }
+
+  /**
+   * 清空搜索条件
+   */
+  function clear() {
+    search("", "");
+  }
+
+  return {
+    keyword,
+    mediaType,
+    results,
+    queryError,
+    isActive,
+    search,
+    clear,
+  };
+}
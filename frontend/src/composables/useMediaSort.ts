@@ -0,0 +1,48 @@
+import {ref} from "vue";
+import {EventsOn} from "../../wailsjs/runtime";
+import {GetSortOptions, SetSortOptions} from "../../wailsjs/go/app/App";
+import type {SortField, SortOptions} from "@/types";
+
+// 全局状态，在模块加载时就创建
+const sortOptions = ref<SortOptions>({field: 'modTime', desc: true});
+
+/**
+ * 从后端读取当前目录记住的排序选项
+ */
+async function loadSortOptions() {
+  try {
+    sortOptions.value = (await GetSortOptions()) as SortOptions;
+  } catch (error) {
+    console.error("读取排序选项失败:", error);
+  }
+}
+
+// 切换目录后重新读取该目录的排序选项
+EventsOn("selected-dir", () => {
+  loadSortOptions();
+});
+
+/**
+ * 媒体排序 composable，排序选项按目录记住
+ */
+export function useMediaSort() {
+
+  /**
+   * 修改排序选项，后端排序完成后会推送新的列表
+   */
+  async function changeSort(field: SortField, desc: boolean) {
+    const previous = sortOptions.value;
+    sortOptions.value = {field, desc};
+    try {
+      await SetSortOptions(sortOptions.value);
+    } catch (error) {
+      sortOptions.value = previous;
+      console.error("设置排序选项失败:", error);
+    }
+  }
+
+  return {
+    sortOptions,
+    changeSort,
+  };
+}
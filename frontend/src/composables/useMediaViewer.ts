@@ -93,7 +93,13 @@ export function useMediaViewer(
     if (list.length === 0 || curIdx < 0 || curIdx >= list.length) {
       return;
     }
-    await RemoveMedia(mediaList.value[curIdx].path)
+    // 移动失败时文件仍在原位置，保留在列表中
+    try {
+      await RemoveMedia(mediaList.value[curIdx].path)
+    } catch (e) {
+      console.error('删除文件失败', e)
+      return;
+    }
 
     // 移除当前索引对应的媒体项
     mediaList.value.splice(curIdx, 1);
@@ -0,0 +1,84 @@
+import {computed, ref} from "vue";
+import {EventsOn} from "../../wailsjs/runtime";
+import {ApplyRenamePlan, PlanRename} from "../../wailsjs/go/app/App";
+import {file} from "../../wailsjs/go/models";
+
+// 默认模板，与修复文件名相同
+const defaultTemplate = "{seq:4}{ext}";
+
+// 全局状态，菜单触发 rename-preview 事件后显示预览
+const plan = ref<file.RenamePlan | null>(null);
+const error = ref("");
+const isOpen = ref(false);
+const isApplying = ref(false);
+// 重命名选项，关闭预览后保留，下次打开继续使用
+const options = ref(new file.RenameOptions({template: defaultTemplate, sortBy: "modTime", start: 1, autoWidth: false, types: [], separateSeq: false}));
+
+EventsOn("rename-preview", () => {
+  preview();
+});
+
+/**
+ * 按当前选项生成重命名计划，不修改文件
+ */
+async function preview() {
+  isOpen.value = true;
+  plan.value = null;
+  error.value = "";
+  try {
+    plan.value = await PlanRename(options.value);
+  } catch (err) {
+    error.value = String(err);
+  }
+}
+
+/**
+ * 文件名修复预览 composable，确认后严格按预览的计划执行
+ */
+export function useRenamePlan() {
+  const canApply = computed(() =>
+    !!plan.value && plan.value.ops.length > 0 && plan.value.conflicts.length === 0 && !isApplying.value);
+
+  /**
+   * 执行预览的计划，文件在预览后发生变化时返回错误，需要重新预览
+   */
+  async function apply() {
+    if (!plan.value || !canApply.value) return;
+    isApplying.value = true;
+    error.value = "";
+    try {
+      await ApplyRenamePlan(plan.value);
+      close();
+    } catch (err) {
+      error.value = String(err);
+    } finally {
+      isApplying.value = false;
+    }
+  }
+
+  /**
+   * 恢复默认模板与选项
+   */
+  function resetOptions() {
+    options.value = new file.RenameOptions({template: defaultTemplate, sortBy: "modTime", start: 1, autoWidth: false, types: [], separateSeq: false});
+  }
+
+  function close() {
+    isOpen.value = false;
+    plan.value = null;
+    error.value = "";
+  }
+
+  return {
+    plan,
+    options,
+    error,
+    isOpen,
+    isApplying,
+    canApply,
+    preview,
+    apply,
+    resetOptions,
+    close,
+  };
+}
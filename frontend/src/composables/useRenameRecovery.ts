@@ -0,0 +1,60 @@
+import {ref} from "vue";
+import {EventsOn} from "../../wailsjs/runtime";
+import {GetInterruptedRenames, ResumeRename, RevertRename} from "../../wailsjs/go/app/App";
+import type {file} from "../../wailsjs/go/models";
+
+// 全局状态，启动时存在未完成的重命名则提示继续或撤销
+const journals = ref<file.RenameJournal[]>([]);
+const error = ref("");
+const pendingDir = ref("");
+
+EventsOn("rename-interrupted", (data: file.RenameJournal[]) => {
+  journals.value = data ?? [];
+});
+
+/**
+ * 中断的重命名恢复 composable
+ */
+export function useRenameRecovery() {
+  /**
+   * 加载未完成的重命名，前端加载晚于启动事件时使用
+   */
+  async function load() {
+    try {
+      journals.value = (await GetInterruptedRenames()) ?? [];
+    } catch (err) {
+      error.value = String(err);
+    }
+  }
+
+  /**
+   * 继续或撤销目录中断的重命名，成功后从列表中移除
+   */
+  async function recover(dir: string, resume: boolean) {
+    pendingDir.value = dir;
+    error.value = "";
+    try {
+      await (resume ? ResumeRename(dir) : RevertRename(dir));
+      journals.value = journals.value.filter(journal => journal.dir !== dir);
+    } catch (err) {
+      error.value = String(err);
+    } finally {
+      pendingDir.value = "";
+    }
+  }
+
+  function dismiss() {
+    journals.value = [];
+    error.value = "";
+  }
+
+  return {
+    journals,
+    error,
+    pendingDir,
+    load,
+    resume: (dir: string) => recover(dir, true),
+    revert: (dir: string) => recover(dir, false),
+    dismiss,
+  };
+}
@@ -0,0 +1,29 @@
+import {ref} from "vue";
+import {EventsOn} from "../../wailsjs/runtime";
+import {GetServerStatus} from "../../wailsjs/go/app/App";
+import type {server} from "../../wailsjs/go/models";
+
+// 全局状态，文件服务只在启动时报告一次
+const status = ref<server.Status | null>(null);
+
+// 前端可能在服务启动之后才加载，事件与主动查询同时使用
+EventsOn("server-status", (data: server.Status) => {
+  status.value = data;
+});
+
+GetServerStatus()
+  .then((data) => {
+    status.value = data;
+  })
+  .catch((error) => {
+    console.error("读取文件服务状态失败:", error);
+  });
+
+/**
+ * 文件服务状态 composable
+ */
+export function useServerStatus() {
+  return {
+    status,
+  };
+}
@@ -0,0 +1,36 @@
+/**
+ * 导出内容，paths 与 dir 至少指定一个
+ * 对应后端 handler.ExportRequest 结构
+ */
+export interface ExportRequest {
+  /** ZIP 文件名 */
+  name: string
+  /** 选中的文件 */
+  paths?: string[]
+  /** 导出目录下的全部媒体文件 */
+  dir?: string
+}
+
+/**
+ * 导出进度，通过 export-progress 事件接收
+ * 对应后端 handler.ExportStatus 结构
+ */
+export interface ExportStatus {
+  id: string
+  /** 已写入的文件数 */
+  files: number
+  /** 文件总数 */
+  totalFiles: number
+  /** 已写入的字节数 */
+  bytes: number
+  /** 总字节数 */
+  totalBytes: number
+  /** 正在写入的文件 */
+  current: string
+  /** 是否已结束 */
+  done: boolean
+  /** 是否被取消 */
+  canceled: boolean
+  /** 失败原因 */
+  error?: string
+}
@@ -1,2 +1,3 @@
 export * from './media'
 export * from './shortcut'
+export * from './export'
@@ -12,12 +12,145 @@ export interface MediaInfo {
   path: string
   /** 文件名 */
   name: string
+  /** 相对于所选目录的文件夹，根目录为空 */
+  folder: string
   /** 文件大小（字节） */
   size: number
   /** HTTP 访问 URL */
   url: string
+  /** 缩略图 URL，仅图片 */
+  thumbUrl?: string
   /** 媒体类型 */
   type: MediaType
+  /** 修改时间 */
+  modTime: string
+  /** 像素宽度 */
+  width?: number
+  /** 像素高度 */
+  height?: number
+  /** EXIF 拍摄时间 */
+  captureTime?: string
+  /** EXIF 元数据，读取后才有 */
+  exif?: ExifMeta
+  /** 视频元数据，读取后才有 */
+  video?: VideoMeta
+}
+
+/**
+ * 视频元数据
+ * 对应后端 video.Meta 结构
+ */
+export interface VideoMeta {
+  /** 时长（秒） */
+  duration?: number
+  /** 像素宽度 */
+  width?: number
+  /** 像素高度 */
+  height?: number
+  /** 帧率 */
+  frameRate?: number
+  /** 视频编码 FourCC */
+  codec?: string
+  /** 创建时间 */
+  creationTime?: string
+}
+
+/**
+ * 拍摄位置，南纬与西经为负
+ * 对应后端 exif.GPS 结构
+ */
+export interface GPSInfo {
+  /** 纬度 */
+  latitude: number
+  /** 经度 */
+  longitude: number
+  /** 海拔（米） */
+  altitude?: number
+}
+
+/**
+ * 图片 EXIF 元数据
+ * 对应后端 exif.Meta 结构
+ */
+export interface ExifMeta {
+  /** 拍摄时间 */
+  captureTime?: string
+  /** 相机厂商 */
+  make?: string
+  /** 相机型号 */
+  model?: string
+  /** 镜头型号 */
+  lensModel?: string
+  /** 曝光时间（秒） */
+  exposureTime?: number
+  /** 光圈值 */
+  fNumber?: number
+  /** 焦距（毫米） */
+  focalLength?: number
+  /** 感光度 */
+  iso?: number
+  /** 方向，1-8 */
+  orientation?: number
+  /** EXIF 记录的像素宽度 */
+  width?: number
+  /** EXIF 记录的像素高度 */
+  height?: number
+  /** 拍摄位置 */
+  gps?: GPSInfo
+}
+
+/**
+ * 排序字段
+ */
+export type SortField = 'modTime' | 'name' | 'size' | 'type' | 'captureTime' | 'dimensions' | 'duration'
+
+/**
+ * 排序选项
+ * 对应后端 handler.SortOptions 结构
+ */
+export interface SortOptions {
+  /** 排序字段 */
+  field: SortField
+  /** 是否降序 */
+  desc: boolean
+}
+
+/**
+ * 媒体过滤条件，未设置的字段不参与过滤
+ * 对应后端 handler.MediaQuery 结构
+ */
+export interface MediaQuery {
+  /** 媒体类型 */
+  types?: MediaType[]
+  /** 后缀名，如 .jpg */
+  exts?: string[]
+  /** 最小文件大小（字节） */
+  minSize?: number
+  /** 最大文件大小（字节） */
+  maxSize?: number
+  /** 修改时间不早于 */
+  modAfter?: string
+  /** 修改时间早于 */
+  modBefore?: string
+  /** 文件名通配符或子串 */
+  name?: string
+}
+
+/**
+ * 扫描过程中增量推送的媒体列表
+ * 对应后端 handler.MediaChunk 结构
+ */
+export interface MediaChunk {
+  /** 扫描批次 */
+  scanId: number
+  /** 本批次的媒体 */
+  items: MediaInfo[]
+  /** 本批次在扫描结果中的起始位置 */
+  offset: number
+  /** 扫描是否已完成 */
+  done: boolean
+  /** 扫描完成时的媒体总数 */
+  total: number
 }
 
 /**
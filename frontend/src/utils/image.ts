@@ -0,0 +1,23 @@
+// 缩放适配方式，与文件服务的 fit 参数一致
+export type FitMode = 'contain' | 'cover' | 'fill'
+
+// 文件服务允许的最大边长
+const MAX_DIMENSION = 4096
+
+// 尺寸档位，与文件服务一致，相近的尺寸共用缓存
+const SIZE_BUCKETS = [64, 128, 192, 256, 320, 384, 512, 640, 768, 1024, 1280, 1536, 2048, 3072, MAX_DIMENSION]
+
+/**
+ * 生成文件服务的缩放图片地址，width/height 为 CSS 像素，按设备像素比换算
+ */
+export function resizedUrl(url: string, width: number, height: number, fit: FitMode = 'contain'): string {
+  if (!url || (width <= 0 && height <= 0)) return url
+  const ratio = window.devicePixelRatio || 1
+  const round = (size: number) => SIZE_BUCKETS.find(bucket => bucket >= size * ratio) ?? MAX_DIMENSION
+
+  const result = new URL(url)
+  if (width > 0) result.searchParams.set('w', String(round(width)))
+  if (height > 0) result.searchParams.set('h', String(round(height)))
+  result.searchParams.set('fit', fit)
+  return result.toString()
+}
@@ -0,0 +1,2 @@
+export {resizedUrl} from './image'
+export type {FitMode} from './image'
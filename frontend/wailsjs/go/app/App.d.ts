@@ -1,26 +1,78 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 import {context} from '../models';
+import {file} from '../models';
 import {handler} from '../models';
+import {server} from '../models';
+
+export function ApplyRenamePlan(arg1:file.RenamePlan):Promise<void>;
+
+export function CancelBatchRename(arg1:string):Promise<boolean>;
+
+export function CancelExport(arg1:string):Promise<void>;
 
 export function Context():Promise<context.Context>;
 
+export function ExportCategory(arg1:string):Promise<handler.ExportInfo>;
+
+export function ExportZip(arg1:handler.ExportRequest):Promise<handler.ExportInfo>;
+
+export function GetBatchRename():Promise<handler.BatchJob>;
+
 export function GetClassifyDir():Promise<string>;
 
+export function GetInterruptedRenames():Promise<Array<file.RenameJournal>>;
+
+export function GetMediaDetail(arg1:string):Promise<handler.MediaInfo>;
+
+export function GetMediaPage(arg1:number,arg2:number):Promise<handler.MediaPage>;
+
+export function GetRoots():Promise<Array<handler.Root>>;
+
+export function GetScanOptions():Promise<handler.ScanOptions>;
+
+export function GetServerStatus():Promise<server.Status>;
+
 export function GetShortcuts():Promise<Array<handler.ShortcutConfig>>;
 
+export function GetSortOptions():Promise<handler.SortOptions>;
+
 export function GetUndoCount():Promise<number>;
 
 export function MoveByShortcut(arg1:string,arg2:string):Promise<void>;
 
+export function OpenDir(arg1:string):Promise<void>;
+
+export function PlanFixNames():Promise<file.RenamePlan>;
+
+export function PlanRename(arg1:file.RenameOptions):Promise<file.RenamePlan>;
+
+export function PrepareExport(arg1:handler.ExportRequest):Promise<handler.ExportInfo>;
+
+export function QueryMedia(arg1:handler.MediaQuery,arg2:number,arg3:number):Promise<handler.MediaPage>;
+
+export function RegisterRoot(arg1:string):Promise<handler.Root>;
+
 export function RemoveMedia(arg1:string):Promise<void>;
 
 export function RemoveSimilarImage(arg1:string):Promise<void>;
 
+export function ResumeRename(arg1:string):Promise<void>;
+
+export function RevertRename(arg1:string):Promise<void>;
+
 export function SaveShortcuts(arg1:Array<handler.ShortcutConfig>):Promise<void>;
 
 export function SelectShortcutTargetDir():Promise<string>;
 
 export function SetClassifyDir(arg1:string):Promise<void>;
 
+export function SetScanOptions(arg1:handler.ScanOptions):Promise<void>;
+
+export function SetSortOptions(arg1:handler.SortOptions):Promise<void>;
+
+export function StartBatchRename(arg1:handler.BatchRenameRequest):Promise<handler.BatchJob>;
+
 export function UndoMove():Promise<void>;
+
+export function UnregisterRoot(arg1:string):Promise<void>;
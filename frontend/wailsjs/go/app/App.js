@@ -2,18 +2,70 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function ApplyRenamePlan(arg1) {
+  return window['go']['app']['App']['ApplyRenamePlan'](arg1);
+}
+
+export function CancelBatchRename(arg1) {
+  return window['go']['app']['App']['CancelBatchRename'](arg1);
+}
+
+export function CancelExport(arg1) {
+  return window['go']['app']['App']['CancelExport'](arg1);
+}
+
 export function Context() {
   return window['go']['app']['App']['Context']();
 }
 
+export function ExportCategory(arg1) {
+  return window['go']['app']['App']['ExportCategory'](arg1);
+}
+
+export function ExportZip(arg1) {
+  return window['go']['app']['App']['ExportZip'](arg1);
+}
+
+export function GetBatchRename() {
+  return window['go']['app']['App']['GetBatchRename']();
+}
+
 export function GetClassifyDir() {
   return window['go']['app']['App']['GetClassifyDir']();
 }
 
+export function GetInterruptedRenames() {
+  return window['go']['app']['App']['GetInterruptedRenames']();
+}
+
+export function GetMediaDetail(arg1) {
+  return window['go']['app']['App']['GetMediaDetail'](arg1);
+}
+
+export function GetMediaPage(arg1, arg2) {
+  return window['go']['app']['App']['GetMediaPage'](arg1, arg2);
+}
+
+export function GetRoots() {
+  return window['go']['app']['App']['GetRoots']();
+}
+
+export function GetScanOptions() {
+  return window['go']['app']['App']['GetScanOptions']();
+}
+
+export function GetServerStatus() {
+  return window['go']['app']['App']['GetServerStatus']();
+}
+
 export function GetShortcuts() {
   return window['go']['app']['App']['GetShortcuts']();
 }
 
+export function GetSortOptions() {
+  return window['go']['app']['App']['GetSortOptions']();
+}
+
 export function GetUndoCount() {
   return window['go']['app']['App']['GetUndoCount']();
 }
@@ -22,6 +74,30 @@ export function MoveByShortcut(arg1, arg2) {
   return window['go']['app']['App']['MoveByShortcut'](arg1, arg2);
 }
 
+export function OpenDir(arg1) {
+  return window['go']['app']['App']['OpenDir'](arg1);
+}
+
+export function PlanFixNames() {
+  return window['go']['app']['App']['PlanFixNames']();
+}
+
+export function PlanRename(arg1) {
+  return window['go']['app']['App']['PlanRename'](arg1);
+}
+
+export function PrepareExport(arg1) {
+  return window['go']['app']['App']['PrepareExport'](arg1);
+}
+
+export function QueryMedia(arg1, arg2, arg3) {
+  return window['go']['app']['App']['QueryMedia'](arg1, arg2, arg3);
+}
+
+export function RegisterRoot(arg1) {
+  return window['go']['app']['App']['RegisterRoot'](arg1);
+}
+
 export function RemoveMedia(arg1) {
   return window['go']['app']['App']['RemoveMedia'](arg1);
 }
@@ -30,6 +106,14 @@ export function RemoveSimilarImage(arg1) {
   return window['go']['app']['App']['RemoveSimilarImage'](arg1);
 }
 
+export function ResumeRename(arg1) {
+  return window['go']['app']['App']['ResumeRename'](arg1);
+}
+
+export function RevertRename(arg1) {
+  return window['go']['app']['App']['RevertRename'](arg1);
+}
+
 export function SaveShortcuts(arg1) {
   return window['go']['app']['App']['SaveShortcuts'](arg1);
 }
@@ -42,6 +126,22 @@ export function SetClassifyDir(arg1) {
   return window['go']['app']['App']['SetClassifyDir'](arg1);
 }
 
+export function SetScanOptions(arg1) {
+  return window['go']['app']['App']['SetScanOptions'](arg1);
+}
+
+export function SetSortOptions(arg1) {
+  return window['go']['app']['App']['SetSortOptions'](arg1);
+}
+
+export function StartBatchRename(arg1) {
+  return window['go']['app']['App']['StartBatchRename'](arg1);
+}
+
 export function UndoMove() {
   return window['go']['app']['App']['UndoMove']();
 }
+
+export function UnregisterRoot(arg1) {
+  return window['go']['app']['App']['UnregisterRoot'](arg1);
+}
@@ -1,5 +1,620 @@
+export namespace exif {
+	
+	export class GPS {
+	    latitude: number;
+	    longitude: number;
+	    altitude?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new GPS(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.latitude = source["latitude"];
+	        this.longitude = source["longitude"];
+	        this.altitude = source["altitude"];
+	    }
+	}
+	export class Meta {
+	    // Go type: time
+	    captureTime: any;
+	    make?: string;
+	    model?: string;
+	    lensModel?: string;
+	    exposureTime?: number;
+	    fNumber?: number;
+	    focalLength?: number;
+	    iso?: number;
+	    orientation?: number;
+	    width?: number;
+	    height?: number;
+	    gps?: GPS;
+	
+	    static createFrom(source: any = {}) {
+	        return new Meta(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.captureTime = this.convertValues(source["captureTime"], null);
+	        this.make = source["make"];
+	        this.model = source["model"];
+	        this.lensModel = source["lensModel"];
+	        this.exposureTime = source["exposureTime"];
+	        this.fNumber = source["fNumber"];
+	        this.focalLength = source["focalLength"];
+	        this.iso = source["iso"];
+	        this.orientation = source["orientation"];
+	        this.width = source["width"];
+	        this.height = source["height"];
+	        this.gps = this.convertValues(source["gps"], GPS);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace file {
+	
+	export class BatchDirOptions {
+	    recursive: boolean;
+	    maxDepth: number;
+	    include: string[];
+	    exclude: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new BatchDirOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.recursive = source["recursive"];
+	        this.maxDepth = source["maxDepth"];
+	        this.include = source["include"];
+	        this.exclude = source["exclude"];
+	    }
+	}
+	export class BatchProgress {
+	    total: number;
+	    done: number;
+	    failed: number;
+	    skipped: number;
+	    current: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new BatchProgress(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.total = source["total"];
+	        this.done = source["done"];
+	        this.failed = source["failed"];
+	        this.skipped = source["skipped"];
+	        this.current = source["current"];
+	    }
+	}
+	export class BatchResult {
+	    dir: string;
+	    renamed: number;
+	    skipped?: boolean;
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new BatchResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.dir = source["dir"];
+	        this.renamed = source["renamed"];
+	        this.skipped = source["skipped"];
+	        this.error = source["error"];
+	    }
+	}
+	export class BatchSummary {
+	    total: number;
+	    done: number;
+	    failed: number;
+	    skipped: number;
+	    current: string;
+	    canceled: boolean;
+	    results: BatchResult[];
+	
+	    static createFrom(source: any = {}) {
+	        return new BatchSummary(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.total = source["total"];
+	        this.done = source["done"];
+	        this.failed = source["failed"];
+	        this.skipped = source["skipped"];
+	        this.current = source["current"];
+	        this.canceled = source["canceled"];
+	        this.results = this.convertValues(source["results"], BatchResult);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class RenameConflict {
+	    from: string;
+	    to: string;
+	    reason: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new RenameConflict(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.from = source["from"];
+	        this.to = source["to"];
+	        this.reason = source["reason"];
+	    }
+	}
+	export class RenameJournal {
+	    dir: string;
+	    phase: string;
+	    ops: RenameOp[];
+	    // Go type: time
+	    createdAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new RenameJournal(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.dir = source["dir"];
+	        this.phase = source["phase"];
+	        this.ops = this.convertValues(source["ops"], RenameOp);
+	        this.createdAt = this.convertValues(source["createdAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class RenameOp {
+	    from: string;
+	    to: string;
+	    size: number;
+	    // Go type: time
+	    modTime: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new RenameOp(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.from = source["from"];
+	        this.to = source["to"];
+	        this.size = source["size"];
+	        this.modTime = this.convertValues(source["modTime"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class RenameOptions {
+	    template: string;
+	    sortBy: string;
+	    start?: number;
+	    autoWidth: boolean;
+	    types: string[];
+	    separateSeq: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new RenameOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.template = source["template"];
+	        this.sortBy = source["sortBy"];
+	        this.start = source["start"];
+	        this.autoWidth = source["autoWidth"];
+	        this.types = source["types"];
+	        this.separateSeq = source["separateSeq"];
+	    }
+	}
+	export class RenamePlan {
+	    dir: string;
+	    ops: RenameOp[];
+	    unchanged: string[];
+	    skipped: RenameSkip[];
+	    conflicts: RenameConflict[];
+	
+	    static createFrom(source: any = {}) {
+	        return new RenamePlan(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.dir = source["dir"];
+	        this.ops = this.convertValues(source["ops"], RenameOp);
+	        this.unchanged = source["unchanged"];
+	        this.skipped = this.convertValues(source["skipped"], RenameSkip);
+	        this.conflicts = this.convertValues(source["conflicts"], RenameConflict);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class RenameSkip {
+	    name: string;
+	    reason: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new RenameSkip(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.reason = source["reason"];
+	    }
+	}
+
+}
+
 export namespace handler {
 	
+	export class BatchJob {
+	    id: string;
+	    dir: string;
+	    state: string;
+	    // Go type: time
+	    startedAt: any;
+	    // Go type: time
+	    finishedAt?: any;
+	    progress: file.BatchProgress;
+	    summary?: file.BatchSummary;
+	
+	    static createFrom(source: any = {}) {
+	        return new BatchJob(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.dir = source["dir"];
+	        this.state = source["state"];
+	        this.startedAt = this.convertValues(source["startedAt"], null);
+	        this.finishedAt = this.convertValues(source["finishedAt"], null);
+	        this.progress = this.convertValues(source["progress"], file.BatchProgress);
+	        this.summary = this.convertValues(source["summary"], file.BatchSummary);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class BatchRenameRequest {
+	    template: string;
+	    sortBy: string;
+	    start?: number;
+	    autoWidth: boolean;
+	    types: string[];
+	    separateSeq: boolean;
+	    recursive: boolean;
+	    maxDepth: number;
+	    include: string[];
+	    exclude: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new BatchRenameRequest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.template = source["template"];
+	        this.sortBy = source["sortBy"];
+	        this.start = source["start"];
+	        this.autoWidth = source["autoWidth"];
+	        this.types = source["types"];
+	        this.separateSeq = source["separateSeq"];
+	        this.recursive = source["recursive"];
+	        this.maxDepth = source["maxDepth"];
+	        this.include = source["include"];
+	        this.exclude = source["exclude"];
+	    }
+	}
+	export class ExportInfo {
+	    id: string;
+	    name: string;
+	    url: string;
+	    files: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ExportInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.url = source["url"];
+	        this.files = source["files"];
+	    }
+	}
+	export class ExportRequest {
+	    name: string;
+	    paths: string[];
+	    dir: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ExportRequest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.paths = source["paths"];
+	        this.dir = source["dir"];
+	    }
+	}
+	export class MediaInfo {
+	    path: string;
+	    name: string;
+	    folder: string;
+	    size: number;
+	    url: string;
+	    thumbUrl?: string;
+	    type: string;
+	    // Go type: time
+	    modTime: any;
+	    width?: number;
+	    height?: number;
+	    // Go type: time
+	    captureTime?: any;
+	    exif?: exif.Meta;
+	    video?: video.Meta;
+	
+	    static createFrom(source: any = {}) {
+	        return new MediaInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.name = source["name"];
+	        this.folder = source["folder"];
+	        this.size = source["size"];
+	        this.url = source["url"];
+	        this.thumbUrl = source["thumbUrl"];
+	        this.type = source["type"];
+	        this.modTime = this.convertValues(source["modTime"], null);
+	        this.width = source["width"];
+	        this.height = source["height"];
+	        this.captureTime = this.convertValues(source["captureTime"], null);
+	        this.exif = this.convertValues(source["exif"], exif.Meta);
+	        this.video = this.convertValues(source["video"], video.Meta);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class MediaPage {
+	    items: MediaInfo[];
+	    offset: number;
+	    limit: number;
+	    total: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new MediaPage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.items = this.convertValues(source["items"], MediaInfo);
+	        this.offset = source["offset"];
+	        this.limit = source["limit"];
+	        this.total = source["total"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class MediaQuery {
+	    types: string[];
+	    exts: string[];
+	    minSize: number;
+	    maxSize: number;
+	    // Go type: time
+	    modAfter?: any;
+	    // Go type: time
+	    modBefore?: any;
+	    name: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new MediaQuery(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.types = source["types"];
+	        this.exts = source["exts"];
+	        this.minSize = source["minSize"];
+	        this.maxSize = source["maxSize"];
+	        this.modAfter = this.convertValues(source["modAfter"], null);
+	        this.modBefore = this.convertValues(source["modBefore"], null);
+	        this.name = source["name"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Root {
+	    id: string;
+	    dir: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Root(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.dir = source["dir"];
+	    }
+	}
+	export class ScanOptions {
+	    recursive: boolean;
+	    maxDepth: number;
+	    excludeDirs: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ScanOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.recursive = source["recursive"];
+	        this.maxDepth = source["maxDepth"];
+	        this.excludeDirs = source["excludeDirs"];
+	    }
+	}
 	export class ShortcutConfig {
 	    key: string;
 	    targetDir: string;
@@ -16,6 +631,87 @@ export namespace handler {
 	        this.label = source["label"];
 	    }
 	}
+	export class SortOptions {
+	    field: string;
+	    desc: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new SortOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.field = source["field"];
+	        this.desc = source["desc"];
+	    }
+	}
+
+}
+
+export namespace server {
+	
+	export class Status {
+	    running: boolean;
+	    port: number;
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Status(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.running = source["running"];
+	        this.port = source["port"];
+	        this.error = source["error"];
+	    }
+	}
+
+}
+
+export namespace video {
+	
+	export class Meta {
+	    duration?: number;
+	    width?: number;
+	    height?: number;
+	    frameRate?: number;
+	    codec?: string;
+	    // Go type: time
+	    creationTime?: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new Meta(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.duration = source["duration"];
+	        this.width = source["width"];
+	        this.height = source["height"];
+	        this.frameRate = source["frameRate"];
+	        this.codec = source["codec"];
+	        this.creationTime = this.convertValues(source["creationTime"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 
 }
 
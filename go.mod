@@ -5,10 +5,13 @@ go 1.24.0
 toolchain go1.24.5
 
 require (
+	github.com/HugoSmits86/nativewebp v0.9.3
 	github.com/corona10/goimagehash v1.1.0
+	github.com/fsnotify/fsnotify v1.9.0
 	github.com/stretchr/testify v1.10.0
 	github.com/wailsapp/wails/v2 v2.11.0
 	go.uber.org/zap v1.27.1
+	golang.org/x/image v0.24.0
 	gopkg.in/natefinch/lumberjack.v2 v2.2.1
 )
 
@@ -0,0 +1,405 @@
+// Package api 在文件服务上提供版本化的 JSON REST API，供本机脚本与其他工具调用应用功能。
+// 请求需要携带访问令牌（查询参数 token 或 Authorization: Bearer 请求头），错误以 {"error": "..."} 返回
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"media-app/internal/app"
+	"media-app/internal/handler"
+	"media-app/pkg/file"
+	"media-app/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// Prefix API 的路径前缀
+const Prefix = "/api/v1/"
+
+// maxBodySize 请求体大小上限
+const maxBodySize = 1 << 20
+
+// api 将请求转发给 App 上的处理器
+type api struct {
+	app *app.App
+}
+
+// New 创建 API 服务，挂载在 Prefix 下
+func New(app *app.App) http.Handler {
+	a := &api{app: app}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+Prefix+"dir", a.getDir)
+	mux.HandleFunc("PUT "+Prefix+"dir", a.openDir)
+	mux.HandleFunc("GET "+Prefix+"media", a.listMedia)
+	mux.HandleFunc("DELETE "+Prefix+"media", a.removeMedia)
+	mux.HandleFunc("POST "+Prefix+"media/query", a.queryMedia)
+	mux.HandleFunc("GET "+Prefix+"media/detail", a.mediaDetail)
+	mux.HandleFunc("POST "+Prefix+"media/move", a.moveMedia)
+	mux.HandleFunc("POST "+Prefix+"undo", a.undo)
+	mux.HandleFunc("GET "+Prefix+"shortcuts", a.shortcuts)
+	mux.HandleFunc("GET "+Prefix+"similarity/jobs", a.similarJobs)
+	mux.HandleFunc("POST "+Prefix+"similarity/jobs", a.startSimilarJob)
+	mux.HandleFunc("GET "+Prefix+"similarity/jobs/{id}", a.similarJob)
+	mux.HandleFunc("POST "+Prefix+"exports", a.prepareExport)
+	mux.HandleFunc("GET "+Prefix+"rename/plan", a.renamePlan)
+	mux.HandleFunc("POST "+Prefix+"rename/apply", a.applyRenamePlan)
+	mux.HandleFunc("GET "+Prefix+"rename/batch", a.batchRename)
+	mux.HandleFunc("POST "+Prefix+"rename/batch", a.startBatchRename)
+	mux.HandleFunc("DELETE "+Prefix+"rename/batch/{id}", a.cancelBatchRename)
+	mux.HandleFunc("GET "+Prefix+"rename/journals", a.renameJournals)
+	mux.HandleFunc("POST "+Prefix+"rename/journals/resume", a.recoverRename(a.app.ResumeRename))
+	mux.HandleFunc("POST "+Prefix+"rename/journals/revert", a.recoverRename(a.app.RevertRename))
+	mux.HandleFunc(Prefix, func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, errors.New("接口不存在"))
+	})
+	return mux
+}
+
+// DirState 当前浏览目录
+type DirState struct {
+	Dir       string              `json:"dir"`
+	Scan      handler.ScanOptions `json:"scan"`
+	UndoCount int                 `json:"undoCount"` // 可撤销的移动次数
+}
+
+// getDir 返回当前浏览目录
+func (a *api) getDir(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, a.dirState())
+}
+
+// openDir 切换浏览目录，扫描在后台进行，可轮询 GET media 获取结果
+func (a *api) openDir(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Dir string `json:"dir"`
+	}
+	if !decode(w, r, &req) {
+		return
+	}
+	if req.Dir == "" {
+		writeError(w, http.StatusBadRequest, errors.New("缺少参数 dir"))
+		return
+	}
+	if err := a.app.OpenDir(req.Dir); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	logger.Info("通过 API 打开目录", zap.String("dir", req.Dir))
+	writeJSON(w, http.StatusOK, a.dirState())
+}
+
+// dirState 返回当前浏览目录的状态
+func (a *api) dirState() DirState {
+	return DirState{
+		Dir:       a.app.MediaHandler.GetSelectedDir(),
+		Scan:      a.app.GetScanOptions(),
+		UndoCount: a.app.GetUndoCount(),
+	}
+}
+
+// listMedia 分页返回当前目录的媒体，参数 offset、limit
+func (a *api) listMedia(w http.ResponseWriter, r *http.Request) {
+	offset, limit, err := pageParams(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, a.app.GetMediaPage(offset, limit))
+}
+
+// QueryRequest 媒体过滤请求
+type QueryRequest struct {
+	Query  handler.MediaQuery `json:"query"`
+	Offset int                `json:"offset"`
+	Limit  int                `json:"limit"`
+}
+
+// queryMedia 按条件过滤媒体并分页返回
+func (a *api) queryMedia(w http.ResponseWriter, r *http.Request) {
+	var req QueryRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	page, err := a.app.QueryMedia(req.Query, req.Offset, req.Limit)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+// mediaDetail 返回媒体详情，参数 path 为文件的绝对路径
+func (a *api) mediaDetail(w http.ResponseWriter, r *http.Request) {
+	path, ok := requirePath(w, r)
+	if !ok {
+		return
+	}
+	media, err := a.app.GetMediaDetail(path)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, media)
+}
+
+// removeMedia 将文件移入同目录的 .delete 文件夹，参数 path 为所选目录内文件的绝对路径
+func (a *api) removeMedia(w http.ResponseWriter, r *http.Request) {
+	path, ok := requirePath(w, r)
+	if !ok {
+		return
+	}
+	if err := a.app.RemoveMedia(path); err != nil {
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			writeError(w, http.StatusNotFound, err)
+		case errors.Is(err, handler.ErrOutsideRoot):
+			writeError(w, http.StatusForbidden, err)
+		default:
+			writeError(w, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// MoveRequest 按快捷键分类移动文件
+type MoveRequest struct {
+	Path     string `json:"path"`     // 文件的绝对路径
+	Shortcut string `json:"shortcut"` // 快捷键
+}
+
+// moveMedia 将文件移动到快捷键对应的目标文件夹
+func (a *api) moveMedia(w http.ResponseWriter, r *http.Request) {
+	var req MoveRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if req.Path == "" || req.Shortcut == "" {
+		writeError(w, http.StatusBadRequest, errors.New("缺少参数 path 或 shortcut"))
+		return
+	}
+	if err := a.app.MoveByShortcut(req.Path, req.Shortcut); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, a.app.ShortcutHandler.GetLastMoveRecord())
+}
+
+// undo 撤销上一次移动
+func (a *api) undo(w http.ResponseWriter, _ *http.Request) {
+	if err := a.app.UndoMove(); err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int{"undoCount": a.app.GetUndoCount()})
+}
+
+// shortcuts 返回快捷键配置
+func (a *api) shortcuts(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, a.app.GetShortcuts())
+}
+
+// similarJobs 返回相似度分析任务列表
+func (a *api) similarJobs(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, a.app.SimilarHandler.Jobs())
+}
+
+// startSimilarJob 在后台分析当前目录的相似图片，返回的任务可轮询获取结果
+func (a *api) startSimilarJob(w http.ResponseWriter, _ *http.Request) {
+	job, err := a.app.SimilarHandler.StartJob()
+	if err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	w.Header().Set("Location", Prefix+"similarity/jobs/"+job.ID)
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+// similarJob 返回任务状态，完成后包含分析结果
+func (a *api) similarJob(w http.ResponseWriter, r *http.Request) {
+	job, ok := a.app.SimilarHandler.Job(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, errors.New("任务不存在"))
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
+// prepareExport 创建导出任务，返回的地址可直接下载 ZIP
+func (a *api) prepareExport(w http.ResponseWriter, r *http.Request) {
+	var req handler.ExportRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	info, err := a.app.PrepareExport(req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, info)
+}
+
+// renamePlan 按查询参数 template、sortBy、start、autoWidth、types（逗号分隔）、separateSeq
+// 预览当前目录的重命名，不修改文件，参数为空时与修复文件名相同
+func (a *api) renamePlan(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	options := file.RenameOptions{
+		Template:    query.Get("template"),
+		SortBy:      file.RenameSort(query.Get("sortBy")),
+		AutoWidth:   query.Get("autoWidth") == "true",
+		SeparateSeq: query.Get("separateSeq") == "true",
+	}
+	for _, t := range strings.Split(query.Get("types"), ",") {
+		if t != "" {
+			options.Types = append(options.Types, file.MediaType(t))
+		}
+	}
+	if start := query.Get("start"); start != "" {
+		n, err := strconv.Atoi(start)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("无效的参数 start：%s", start))
+			return
+		}
+		options.Start = &n
+	}
+	plan, err := a.app.PlanRename(options)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, plan)
+}
+
+// applyRenamePlan 严格按请求体中预览得到的计划重命名
+func (a *api) applyRenamePlan(w http.ResponseWriter, r *http.Request) {
+	var plan file.RenamePlan
+	if !decode(w, r, &plan) {
+		return
+	}
+	if err := a.app.ApplyRenamePlan(plan); err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// batchRename 返回最近一次批量重命名任务
+func (a *api) batchRename(w http.ResponseWriter, _ *http.Request) {
+	job, ok := a.app.MediaHandler.BatchRenameJob()
+	if !ok {
+		writeError(w, http.StatusNotFound, errors.New("没有批量重命名任务"))
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
+// startBatchRename 按请求体中的选项在后台重命名当前目录下的子文件夹，
+// 请求体包含重命名选项与 recursive、maxDepth、include、exclude
+func (a *api) startBatchRename(w http.ResponseWriter, r *http.Request) {
+	var req handler.BatchRenameRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	job, err := a.app.StartBatchRename(req)
+	if err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+// cancelBatchRename 取消执行中的批量重命名任务
+func (a *api) cancelBatchRename(w http.ResponseWriter, r *http.Request) {
+	if !a.app.CancelBatchRename(r.PathValue("id")) {
+		writeError(w, http.StatusNotFound, errors.New("任务不存在或已结束"))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// renameJournals 列出未完成的重命名
+func (a *api) renameJournals(w http.ResponseWriter, _ *http.Request) {
+	journals, err := a.app.GetInterruptedRenames()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, journals)
+}
+
+// RecoverRequest 继续或撤销中断的重命名
+type RecoverRequest struct {
+	Dir string `json:"dir"`
+}
+
+// recoverRename 继续或撤销请求体中目录的中断重命名
+func (a *api) recoverRename(action func(dir string) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req RecoverRequest
+		if !decode(w, r, &req) {
+			return
+		}
+		if err := action(req.Dir); err != nil {
+			writeError(w, http.StatusConflict, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// pageParams 解析分页参数，缺省时为 0
+func pageParams(r *http.Request) (offset, limit int, err error) {
+	query := r.URL.Query()
+	for _, param := range []struct {
+		name  string
+		value *int
+	}{{"offset", &offset}, {"limit", &limit}} {
+		text := query.Get(param.name)
+		if text == "" {
+			continue
+		}
+		if *param.value, err = strconv.Atoi(text); err != nil {
+			return 0, 0, fmt.Errorf("无效的参数 %s：%s", param.name, text)
+		}
+	}
+	return offset, limit, nil
+}
+
+// requirePath 读取查询参数 path，缺少时写入错误响应
+func requirePath(w http.ResponseWriter, r *http.Request) (string, bool) {
+	path := r.URL.Query().Get("path")
+	if path == "" {
+		writeError(w, http.StatusBadRequest, errors.New("缺少参数 path"))
+		return "", false
+	}
+	return path, true
+}
+
+// decode 解析 JSON 请求体，失败时写入错误响应
+func decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxBodySize)).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("无效的请求体：%w", err))
+		return false
+	}
+	return true
+}
+
+// writeJSON 写入 JSON 响应
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logger.Warn("写入 API 响应失败", zap.Error(err))
+	}
+}
+
+// writeError 写入错误响应
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
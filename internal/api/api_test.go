@@ -0,0 +1,182 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"media-app/internal/app"
+	"media-app/internal/handler"
+	"media-app/pkg/file"
+	"media-app/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	cfg := logger.DefaultConfig()
+	cfg.FileName = filepath.Join(os.TempDir(), "media-app-test", "app.log")
+	cfg.OutputConsole = false
+	if err := logger.Init(cfg); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// newTestAPI 创建使用临时配置目录的应用与 API 服务
+func newTestAPI(t *testing.T) (*app.App, *httptest.Server) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	a := app.New(0)
+	t.Cleanup(func() { a.MediaHandler.Close() })
+	s := httptest.NewServer(New(a))
+	t.Cleanup(s.Close)
+	return a, s
+}
+
+// call 发送 JSON 请求并解析响应，out 为 nil 时不解析
+func call(t *testing.T, s *httptest.Server, method, path string, body, out any) int {
+	t.Helper()
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		assert.Nil(t, err)
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req, err := http.NewRequest(method, s.URL+path, reader)
+	assert.Nil(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+	if out != nil {
+		assert.Nil(t, json.NewDecoder(resp.Body).Decode(out))
+	}
+	return resp.StatusCode
+}
+
+// writeJPEG 写入纯色 JPEG 图片
+func writeJPEG(t *testing.T, path string, c color.Color) {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	for i := 0; i < len(img.Pix); i += 4 {
+		r, g, b, _ := c.RGBA()
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = uint8(r>>8), uint8(g>>8), uint8(b>>8), 255
+	}
+	f, err := os.Create(path)
+	assert.Nil(t, err)
+	defer f.Close()
+	assert.Nil(t, jpeg.Encode(f, img, nil))
+}
+
+// openDir 打开目录并等待扫描完成
+func openDir(t *testing.T, s *httptest.Server, dir string, count int) {
+	t.Helper()
+	var state DirState
+	assert.Equal(t, http.StatusOK, call(t, s, http.MethodPut, Prefix+"dir", map[string]string{"dir": dir}, &state))
+	assert.Equal(t, dir, state.Dir)
+	assert.Eventually(t, func() bool {
+		var page handler.MediaPage
+		call(t, s, http.MethodGet, Prefix+"media", nil, &page)
+		return page.Total == count
+	}, 5*time.Second, 20*time.Millisecond)
+}
+
+func TestMediaRoutes(t *testing.T) {
+	a, s := newTestAPI(t)
+	dir := t.TempDir()
+	writeJPEG(t, filepath.Join(dir, "a.jpg"), color.White)
+	writeJPEG(t, filepath.Join(dir, "b.jpg"), color.Black)
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "c.mp4"), []byte("video"), 0644))
+	openDir(t, s, dir, 3)
+
+	var page handler.MediaPage
+	assert.Equal(t, http.StatusOK, call(t, s, http.MethodGet, Prefix+"media?offset=1&limit=1", nil, &page))
+	assert.Equal(t, 3, page.Total)
+	assert.Len(t, page.Items, 1)
+	assert.Equal(t, http.StatusBadRequest, call(t, s, http.MethodGet, Prefix+"media?limit=x", nil, nil))
+
+	query := QueryRequest{Query: handler.MediaQuery{Types: []file.MediaType{file.MediaTypeVideo}}}
+	assert.Equal(t, http.StatusOK, call(t, s, http.MethodPost, Prefix+"media/query", query, &page))
+	assert.Equal(t, 1, page.Total)
+	assert.Equal(t, "c.mp4", page.Items[0].Name)
+
+	var media handler.MediaInfo
+	assert.Equal(t, http.StatusOK, call(t, s, http.MethodGet, Prefix+"media/detail?path="+filepath.Join(dir, "a.jpg"), nil, &media))
+	assert.Equal(t, "a.jpg", media.Name)
+
+	// 移动、撤销与删除
+	assert.Nil(t, a.SaveShortcuts([]handler.ShortcutConfig{{Key: "1", TargetDir: "keep", Label: "保留"}}))
+	var record handler.MoveRecord
+	assert.Equal(t, http.StatusOK, call(t, s, http.MethodPost, Prefix+"media/move", MoveRequest{Path: filepath.Join(dir, "a.jpg"), Shortcut: "1"}, &record))
+	assert.FileExists(t, record.TargetPath)
+	assert.Equal(t, http.StatusBadRequest, call(t, s, http.MethodPost, Prefix+"media/move", MoveRequest{Path: filepath.Join(dir, "b.jpg"), Shortcut: "9"}, nil))
+
+	var undo map[string]int
+	assert.Equal(t, http.StatusOK, call(t, s, http.MethodPost, Prefix+"undo", nil, &undo))
+	assert.Equal(t, 0, undo["undoCount"])
+	assert.FileExists(t, filepath.Join(dir, "a.jpg"))
+	assert.Equal(t, http.StatusConflict, call(t, s, http.MethodPost, Prefix+"undo", nil, nil))
+
+	assert.Equal(t, http.StatusNoContent, call(t, s, http.MethodDelete, Prefix+"media?path="+filepath.Join(dir, "b.jpg"), nil, nil))
+	assert.FileExists(t, filepath.Join(dir, ".delete", "b.jpg"))
+	assert.Equal(t, http.StatusBadRequest, call(t, s, http.MethodDelete, Prefix+"media", nil, nil))
+	assert.Equal(t, http.StatusNotFound, call(t, s, http.MethodDelete, Prefix+"media?path="+filepath.Join(dir, "b.jpg"), nil, nil))
+
+	// 不删除所选目录外的文件
+	outside := filepath.Join(t.TempDir(), "x.jpg")
+	writeJPEG(t, outside, color.White)
+	assert.Equal(t, http.StatusForbidden, call(t, s, http.MethodDelete, Prefix+"media?path="+outside, nil, nil))
+	assert.FileExists(t, outside)
+
+	// 移动失败时返回错误，而不是 204
+	sub := filepath.Join(dir, "sub")
+	assert.Nil(t, os.Mkdir(sub, 0755))
+	writeJPEG(t, filepath.Join(sub, "d.jpg"), color.White)
+	assert.Nil(t, os.WriteFile(filepath.Join(sub, ".delete"), []byte("file"), 0644))
+	assert.Equal(t, http.StatusInternalServerError, call(t, s, http.MethodDelete, Prefix+"media?path="+filepath.Join(sub, "d.jpg"), nil, nil))
+	assert.FileExists(t, filepath.Join(sub, "d.jpg"))
+}
+
+func TestSimilarityJobs(t *testing.T) {
+	_, s := newTestAPI(t)
+	assert.Equal(t, http.StatusConflict, call(t, s, http.MethodPost, Prefix+"similarity/jobs", nil, nil))
+
+	dir := t.TempDir()
+	writeJPEG(t, filepath.Join(dir, "a.jpg"), color.White)
+	writeJPEG(t, filepath.Join(dir, "b.jpg"), color.White)
+	openDir(t, s, dir, 2)
+
+	var job handler.SimilarJob
+	assert.Equal(t, http.StatusAccepted, call(t, s, http.MethodPost, Prefix+"similarity/jobs", nil, &job))
+	assert.NotEmpty(t, job.ID)
+	assert.Eventually(t, func() bool {
+		call(t, s, http.MethodGet, Prefix+"similarity/jobs/"+job.ID, nil, &job)
+		return job.State == handler.JobDone
+	}, 5*time.Second, 20*time.Millisecond)
+	assert.Equal(t, 1, job.Groups)
+	assert.Len(t, job.Results[0].Images, 2)
+
+	var jobs []handler.SimilarJob
+	assert.Equal(t, http.StatusOK, call(t, s, http.MethodGet, Prefix+"similarity/jobs", nil, &jobs))
+	assert.Len(t, jobs, 1)
+	assert.Nil(t, jobs[0].Results)
+	assert.Equal(t, http.StatusNotFound, call(t, s, http.MethodGet, Prefix+"similarity/jobs/missing", nil, nil))
+}
+
+func TestUnknownRoute(t *testing.T) {
+	_, s := newTestAPI(t)
+	var body map[string]string
+	assert.Equal(t, http.StatusNotFound, call(t, s, http.MethodGet, Prefix+"missing", nil, &body))
+	assert.NotEmpty(t, body["error"])
+	assert.Equal(t, http.StatusBadRequest, call(t, s, http.MethodPut, Prefix+"dir", nil, nil))
+}
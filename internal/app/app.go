@@ -2,11 +2,21 @@ package app
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"media-app/pkg/event"
+	"media-app/pkg/file"
+	"media-app/pkg/index"
 	"media-app/pkg/logger"
+	"media-app/pkg/thumb"
 
 	"media-app/internal/handler"
 	"media-app/internal/server"
 
+	"github.com/wailsapp/wails/v2/pkg/runtime"
 	"go.uber.org/zap"
 )
 
@@ -14,33 +24,120 @@ import (
 type App struct {
 	ctx context.Context
 
+	Events          *event.Bus
 	MediaHandler    *handler.MediaHandler
 	SimilarHandler  *handler.SimilarHandler
 	ShortcutHandler *handler.ShortcutHandler
+	ExportHandler   *handler.ExportHandler
 	HttpServer      *server.HttpServer
 }
 
 // New creates a new App application struct
 func New(filePort int) *App {
-	mediaHandler := handler.NewMediaHandler(filePort)
-	similarHandler := handler.NewSimilarHandler(filePort)
-	shortcutHandler := handler.NewShortcutHandler(filePort)
-	httpServer := server.NewHttpServer(filePort, mediaHandler)
+	urls := handler.NewURLBuilder(filePort)
+	events := event.NewBus()
+	store := index.NewStore(filepath.Join(handler.ConfigDir(), "index"))
+	file.SetJournalDir(filepath.Join(handler.ConfigDir(), "journals"))
+	mediaHandler := handler.NewMediaHandler(urls, store, events, handler.ConfigDir())
+	similarHandler := handler.NewSimilarHandler(urls, store, events)
+	shortcutHandler := handler.NewShortcutHandler(urls)
+	exportHandler := handler.NewExportHandler(urls, events)
+	thumbs := thumb.NewService(filepath.Join(handler.ConfigDir(), "thumbs"), 0)
+	httpServer := server.NewHttpServer(urls, thumbs, exportHandler, events)
 	return &App{
+		Events:          events,
 		HttpServer:      httpServer,
 		MediaHandler:    mediaHandler,
 		SimilarHandler:  similarHandler,
 		ShortcutHandler: shortcutHandler,
+		ExportHandler:   exportHandler,
 	}
 }
 
 // Startup is called when the app starts
 func (a *App) Startup(ctx context.Context) {
 	a.ctx = ctx
+	// 事件同时发送给 Wails 前端与 /_events 的订阅者
+	a.Events.SetForward(func(name string, data any) {
+		runtime.EventsEmit(ctx, name, data)
+	})
 	a.MediaHandler.SetContext(ctx)
 	a.SimilarHandler.SetContext(ctx)
 	a.ShortcutHandler.SetContext(ctx)
-	a.HttpServer.Start()
+	a.ExportHandler.SetContext(ctx)
+	if err := StartServer(a); err != nil {
+		logger.Error("文件服务启动失败", zap.Error(err))
+	}
+	// 前端可能尚未加载，启动结果同时通过 GetServerStatus 提供
+	a.Events.Emit("server-status", a.HttpServer.Status())
+	if journals, err := a.MediaHandler.InterruptedRenames(); err == nil && len(journals) > 0 {
+		logger.Warn("存在未完成的重命名", zap.Int("count", len(journals)))
+		a.Events.Emit("rename-interrupted", journals)
+	}
+}
+
+// Shutdown is called when the app is closing
+func (a *App) Shutdown(_ context.Context) {
+	a.MediaHandler.Close()
+	a.HttpServer.Stop()
+	_ = os.Remove(ServerInfoPath())
+}
+
+// ServerInfo 文件服务的地址与访问令牌，写入配置目录供本机脚本调用 REST API
+type ServerInfo struct {
+	URL   string `json:"url"`
+	Token string `json:"token"`
+	PID   int    `json:"pid"`
+}
+
+// ServerInfoPath 返回服务信息文件路径
+func ServerInfoPath() string {
+	return filepath.Join(handler.ConfigDir(), "server.json")
+}
+
+// StartServer 启动文件服务并写入服务信息文件，无界面运行时也可调用。
+// 定义为函数而不是方法，避免被绑定到前端
+func StartServer(a *App) error {
+	if err := a.HttpServer.Start(); err != nil {
+		return err
+	}
+	a.writeServerInfo()
+	return nil
+}
+
+// writeServerInfo 写入服务信息文件，只允许当前用户读取
+func (a *App) writeServerInfo() {
+	data, err := json.MarshalIndent(ServerInfo{
+		URL:   a.HttpServer.BaseURL(),
+		Token: a.HttpServer.Token(),
+		PID:   os.Getpid(),
+	}, "", "  ")
+	if err == nil {
+		err = os.WriteFile(ServerInfoPath(), data, 0600)
+	}
+	if err != nil {
+		logger.Error("写入服务信息失败", zap.Error(err))
+	}
+}
+
+// GetServerStatus 获取文件服务状态
+func (a *App) GetServerStatus() server.Status {
+	return a.HttpServer.Status()
+}
+
+// RegisterRoot 注册文件服务允许访问的根目录，返回根目录 ID
+func (a *App) RegisterRoot(dir string) (handler.Root, error) {
+	return a.HttpServer.RegisterRoot(dir)
+}
+
+// UnregisterRoot 注销文件服务的根目录
+func (a *App) UnregisterRoot(dir string) {
+	a.HttpServer.UnregisterRoot(dir)
+}
+
+// GetRoots 获取文件服务已注册的根目录
+func (a *App) GetRoots() []handler.Root {
+	return a.HttpServer.Roots()
 }
 
 // Context returns the application context
@@ -48,12 +145,128 @@ func (a *App) Context() context.Context {
 	return a.ctx
 }
 
+// OpenDir 选择要浏览的目录并开始扫描
+func (a *App) OpenDir(dir string) error {
+	if dir == "" {
+		return fmt.Errorf("目录不能为空")
+	}
+	// 命令行传入的相对路径转换为绝对路径，文件服务按绝对路径计算相对路径
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("解析目录失败: %w", err)
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("读取目录失败: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("不是目录: %s", dir)
+	}
+	a.MediaHandler.SetSelectedDir(dir)
+	a.SimilarHandler.SetSelectedDir(dir)
+	a.ShortcutHandler.SetSelectedDir(dir)
+	fileCount, err := file.CountFiles(dir)
+	if err != nil {
+		return fmt.Errorf("读取文件失败: %w", err)
+	}
+	// 递归模式下顶层目录可能没有文件，但子目录中仍有媒体
+	if fileCount != 0 || a.MediaHandler.GetScanOptions().Recursive {
+		a.MediaHandler.LoadMediaFiles(fileCount)
+	}
+	return nil
+}
+
 // RemoveMedia 删除媒体资源
 func (a *App) RemoveMedia(path string) error {
 	logger.Info("删除文件", zap.String("path", path))
 	return a.MediaHandler.RemoveMedia(path)
 }
 
+// GetScanOptions 获取目录扫描选项
+func (a *App) GetScanOptions() handler.ScanOptions {
+	return a.MediaHandler.GetScanOptions()
+}
+
+// SetScanOptions 设置目录扫描选项并重新扫描
+func (a *App) SetScanOptions(options handler.ScanOptions) {
+	a.MediaHandler.SetScanOptions(options)
+	a.MediaHandler.RefreshMediaFiles()
+}
+
+// GetMediaPage 分页获取当前目录的媒体列表
+func (a *App) GetMediaPage(offset, limit int) handler.MediaPage {
+	return a.MediaHandler.GetMediaPage(offset, limit)
+}
+
+// QueryMedia 按条件过滤当前目录的媒体并分页返回
+func (a *App) QueryMedia(query handler.MediaQuery, offset, limit int) (handler.MediaPage, error) {
+	return a.MediaHandler.QueryMedia(query, offset, limit)
+}
+
+// GetMediaDetail 获取媒体详情，包含 EXIF 元数据
+func (a *App) GetMediaDetail(path string) (handler.MediaInfo, error) {
+	return a.MediaHandler.GetMediaDetail(path)
+}
+
+// GetSortOptions 获取当前目录的排序选项
+func (a *App) GetSortOptions() handler.SortOptions {
+	return a.MediaHandler.GetSortOptions()
+}
+
+// SetSortOptions 设置当前目录的排序选项
+func (a *App) SetSortOptions(options handler.SortOptions) error {
+	return a.MediaHandler.SetSortOptions(options)
+}
+
+// PlanFixNames 预览所选目录的文件名修复
+func (a *App) PlanFixNames() (file.RenamePlan, error) {
+	return a.MediaHandler.PlanFixMediaFilename()
+}
+
+// PlanRename 按模板预览所选目录的重命名
+func (a *App) PlanRename(options file.RenameOptions) (file.RenamePlan, error) {
+	return a.MediaHandler.PlanRenameMedia(options)
+}
+
+// ApplyRenamePlan 执行已预览的重命名计划
+func (a *App) ApplyRenamePlan(plan file.RenamePlan) error {
+	logger.Info("执行重命名计划", zap.String("dir", plan.Dir), zap.Int("files", len(plan.Ops)))
+	return a.MediaHandler.ApplyRenamePlan(plan)
+}
+
+// StartBatchRename 在后台按选项重命名所选目录下的子文件夹
+func (a *App) StartBatchRename(req handler.BatchRenameRequest) (handler.BatchJob, error) {
+	return a.MediaHandler.StartBatchRename(req)
+}
+
+// CancelBatchRename 取消批量重命名任务
+func (a *App) CancelBatchRename(id string) bool {
+	return a.MediaHandler.CancelBatchRename(id)
+}
+
+// GetBatchRename 获取最近一次批量重命名任务，没有任务时 ID 为空
+func (a *App) GetBatchRename() handler.BatchJob {
+	job, _ := a.MediaHandler.BatchRenameJob()
+	return job
+}
+
+// GetInterruptedRenames 获取上次未完成的重命名
+func (a *App) GetInterruptedRenames() ([]file.RenameJournal, error) {
+	return a.MediaHandler.InterruptedRenames()
+}
+
+// ResumeRename 继续执行中断的重命名
+func (a *App) ResumeRename(dir string) error {
+	logger.Info("继续中断的重命名", zap.String("dir", dir))
+	return a.MediaHandler.ResumeRename(dir)
+}
+
+// RevertRename 撤销中断的重命名
+func (a *App) RevertRename(dir string) error {
+	logger.Info("撤销中断的重命名", zap.String("dir", dir))
+	return a.MediaHandler.RevertRename(dir)
+}
+
 // RemoveSimilarImage 删除相似图片
 func (a *App) RemoveSimilarImage(path string) error {
 	logger.Info("删除相似图片", zap.String("path", path))
@@ -94,6 +307,30 @@ func (a *App) GetUndoCount() int {
 	return a.ShortcutHandler.GetUndoCount()
 }
 
+// ExportZip 弹出保存对话框，将选中的文件导出为 ZIP，进度通过 export-progress 事件发送
+func (a *App) ExportZip(req handler.ExportRequest) (handler.ExportInfo, error) {
+	return a.ExportHandler.ExportToFile(req)
+}
+
+// ExportCategory 将快捷键对应分类目标文件夹中的媒体导出为 ZIP
+func (a *App) ExportCategory(shortcutKey string) (handler.ExportInfo, error) {
+	shortcut, dir, err := a.ShortcutHandler.CategoryDir(shortcutKey)
+	if err != nil {
+		return handler.ExportInfo{}, err
+	}
+	return a.ExportHandler.ExportToFile(handler.ExportRequest{Name: shortcut.Label, Dir: dir})
+}
+
+// PrepareExport 创建导出任务，返回可通过文件服务下载 ZIP 的地址
+func (a *App) PrepareExport(req handler.ExportRequest) (handler.ExportInfo, error) {
+	return a.ExportHandler.Prepare(req)
+}
+
+// CancelExport 取消导出
+func (a *App) CancelExport(id string) {
+	a.ExportHandler.Cancel(id)
+}
+
 // SetClassifyDir 设置分类目录
 func (a *App) SetClassifyDir(dir string) {
 	a.ShortcutHandler.SetSelectedDir(dir)
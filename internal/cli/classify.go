@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"context"
+
+	"media-app/internal/app"
+	"media-app/internal/handler"
+)
+
+// runClassify 按规则文件将目录中的媒体移动到快捷键对应的分类文件夹
+func runClassify(_ context.Context, e *env, args []string) int {
+	fs := newFlagSet(e, "classify")
+	rulesPath := fs.String("rules", "", "JSON 格式的分类规则文件")
+	dryRun := fs.Bool("dry-run", false, "只输出计划的移动，不修改文件")
+	asJSON := fs.Bool("json", false, "以 JSON 输出结果")
+	if err := fs.Parse(args); err != nil {
+		return parseError(err)
+	}
+	if fs.NArg() != 1 || *rulesPath == "" {
+		fs.Usage()
+		return exitUsage
+	}
+
+	rules, err := handler.LoadClassifyRules(*rulesPath)
+	if err != nil {
+		e.errorf("%v\n", err)
+		return exitUsage
+	}
+	a := app.New(0)
+	a.SetClassifyDir(fs.Arg(0))
+	moves, err := a.ShortcutHandler.Classify(fs.Arg(0), rules, *dryRun)
+	if err != nil {
+		e.errorf("%v\n", err)
+		return exitError
+	}
+
+	code := exitOK
+	for _, move := range moves {
+		if move.Error != "" {
+			code = exitError
+		}
+	}
+	if *asJSON {
+		if c := e.printJSON(moves); c != exitOK {
+			return c
+		}
+		return code
+	}
+	for _, move := range moves {
+		if move.Error != "" {
+			e.errorf("%s: %s\n", move.Source, move.Error)
+			continue
+		}
+		e.printf("[%s] %s -> %s\n", move.Shortcut, move.Source, move.Target)
+	}
+	if *dryRun {
+		e.printf("预览 %d 个文件，未移动\n", len(moves))
+	} else {
+		e.printf("已处理 %d 个文件\n", len(moves))
+	}
+	return code
+}
@@ -0,0 +1,119 @@
+// Package cli 无界面的命令行模式，复用应用的处理器执行批量操作，便于在 NAS 上由 cron 调度
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// 退出码
+const (
+	exitOK    = 0
+	exitError = 1 // 执行失败
+	exitUsage = 2 // 参数错误
+)
+
+// command 子命令
+type command struct {
+	usage string // 参数说明
+	brief string // 一句话说明
+	run   func(ctx context.Context, env *env, args []string) int
+}
+
+// commands 支持的子命令，在 init 中初始化以避免与 newFlagSet 形成初始化循环
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"fix-names": {usage: "[-width 4 | -template <模板>] [-sort modTime] [-start 1] [-auto-width] [-types image,video [-separate]] [-batch | -recursive [-depth N] [-include 通配符] [-exclude 通配符]] [-dry-run] [-resume|-revert] <目录>...", brief: "将目录中的文件按修改时间重命名为有序序号，或按模板重命名", run: runFixNames},
+		"dedupe":    {usage: "[-remove] [-json] <目录>", brief: "查找相同图片，-remove 时将重复项移入 .delete", run: runDedupe},
+		"classify":  {usage: "-rules <规则文件> [-dry-run] [-json] <目录>", brief: "按规则将媒体移动到快捷键对应的分类文件夹", run: runClassify},
+		"serve":     {usage: "[-port 8080] [<目录>]", brief: "无界面运行文件服务与 REST API，直到收到中断信号", run: runServe},
+	}
+}
+
+// env 子命令的输出
+type env struct {
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// printf 输出结果
+func (e *env) printf(format string, args ...any) {
+	_, _ = fmt.Fprintf(e.stdout, format, args...)
+}
+
+// errorf 输出错误信息
+func (e *env) errorf(format string, args ...any) {
+	_, _ = fmt.Fprintf(e.stderr, format, args...)
+}
+
+// printJSON 以 JSON 输出结果
+func (e *env) printJSON(v any) int {
+	encoder := json.NewEncoder(e.stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		e.errorf("输出结果失败: %v\n", err)
+		return exitError
+	}
+	return exitOK
+}
+
+// Run 执行子命令，args 不含程序名，返回进程退出码
+func Run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	e := &env{stdout: stdout, stderr: stderr}
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		printUsage(e.stdout)
+		return exitOK
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		e.errorf("未知命令: %s\n\n", args[0])
+		printUsage(e.stderr)
+		return exitUsage
+	}
+	return cmd.run(ctx, e, args[1:])
+}
+
+// newFlagSet 创建子命令的参数解析器，错误信息输出到 stderr
+func newFlagSet(e *env, name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	fs.Usage = func() {
+		e.errorf("用法: media-app %s %s\n", name, commands[name].usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseError 参数解析失败时的退出码，-h 不视为错误
+func parseError(err error) int {
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	return exitUsage
+}
+
+// printUsage 输出命令列表
+func printUsage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("用法: media-app <命令> [参数]\n\n命令:\n")
+	for _, name := range names {
+		fmt.Fprintf(&b, "  %-10s %s\n", name, commands[name].brief)
+		fmt.Fprintf(&b, "  %-10s media-app %s %s\n", "", name, commands[name].usage)
+	}
+	b.WriteString("\n使用 media-app <命令> -h 查看命令的参数\n")
+	_, _ = io.WriteString(w, b.String())
+}
@@ -0,0 +1,262 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/jpeg"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"media-app/internal/app"
+	"media-app/internal/handler"
+	"media-app/pkg/file"
+	"media-app/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	cfg := logger.DefaultConfig()
+	cfg.FileName = filepath.Join(os.TempDir(), "media-app-test", "app.log")
+	cfg.OutputConsole = false
+	if err := logger.Init(cfg); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// run 在临时配置目录下执行命令，返回退出码与输出
+func run(t *testing.T, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := Run(context.Background(), args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+// writeFiles 写入文件并按顺序设置修改时间
+func writeFiles(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	base := time.Now().Add(-time.Hour)
+	for i, name := range names {
+		path := filepath.Join(dir, name)
+		assert.Nil(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.Nil(t, os.WriteFile(path, []byte(name), 0644))
+		modTime := base.Add(time.Duration(i) * time.Minute)
+		assert.Nil(t, os.Chtimes(path, modTime, modTime))
+	}
+}
+
+// listNames 返回目录中的文件名
+func listNames(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	assert.Nil(t, err)
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	return names
+}
+
+func TestUsage(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	code, stdout, _ := run(t)
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, stdout, "fix-names")
+
+	code, _, stderr := run(t, "unknown")
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, "未知命令")
+
+	code, _, _ = run(t, "classify", "-h")
+	assert.Equal(t, exitOK, code)
+	code, _, _ = run(t, "classify", t.TempDir())
+	assert.Equal(t, exitUsage, code)
+}
+
+func TestFixNames(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	root := t.TempDir()
+	writeFiles(t, root, "a/x.jpg", "a/y.jpg", "b/z.mp4", ".delete/old.jpg")
+
+	code, stdout, stderr := run(t, "fix-names", "-dry-run", "-width", "3", filepath.Join(root, "a"))
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Contains(t, stdout, "x.jpg -> 001.jpg")
+	assert.Equal(t, []string{"x.jpg", "y.jpg"}, listNames(t, filepath.Join(root, "a")))
+
+	code, stdout, stderr = run(t, "fix-names", "-dry-run", "-template", "{parent}-{seq}{ext}", "-start", "5", filepath.Join(root, "a"))
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Contains(t, stdout, "y.jpg -> a-6.jpg")
+
+	code, stdout, stderr = run(t, "fix-names", "-dry-run", "-template", "{seq}{ext}", "-start", "0", filepath.Join(root, "a"))
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Contains(t, stdout, "x.jpg -> 0.jpg")
+
+	code, stdout, stderr = run(t, "fix-names", "-dry-run", "-width", "1", "-start", "9", "-auto-width", "-types", "image", filepath.Join(root, "a"))
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Contains(t, stdout, "y.jpg -> 10.jpg")
+
+	code, stdout, stderr = run(t, "fix-names", "-batch", "-width", "3", root)
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Contains(t, stdout, filepath.Join(root, "a"))
+	assert.Equal(t, []string{"001.jpg", "002.jpg"}, listNames(t, filepath.Join(root, "a")))
+	assert.Equal(t, []string{"001.mp4"}, listNames(t, filepath.Join(root, "b")))
+	// 隐藏的整理目录不处理
+	assert.Equal(t, []string{"old.jpg"}, listNames(t, filepath.Join(root, ".delete")))
+
+	archive := t.TempDir()
+	writeFiles(t, archive, "2024/05/trip/p.jpg", "2024/05/trip/q.jpg", "2024/06/r.jpg", "raw/s.jpg")
+	code, stdout, stderr = run(t, "fix-names", "-recursive", "-depth", "2", "-exclude", "raw", "-width", "2", archive)
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Contains(t, stdout, "跳过 2 个")
+	assert.Equal(t, []string{"01.jpg"}, listNames(t, filepath.Join(archive, "2024", "06")))
+	assert.Equal(t, []string{"p.jpg", "q.jpg"}, listNames(t, filepath.Join(archive, "2024", "05", "trip")))
+	assert.Equal(t, []string{"s.jpg"}, listNames(t, filepath.Join(archive, "raw")))
+
+	code, _, stderr = run(t, "fix-names", filepath.Join(root, "missing"))
+	assert.Equal(t, exitError, code)
+	assert.NotEmpty(t, stderr)
+
+	code, _, stderr = run(t, "fix-names", "-revert", filepath.Join(root, "a"))
+	assert.Equal(t, exitError, code)
+	assert.Contains(t, stderr, "没有未完成的重命名")
+}
+
+func TestClassify(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	root := t.TempDir()
+	writeFiles(t, root, "a.jpg", "b.mp4", "c.png", "notes.txt")
+	rules := handler.ClassifyRules{
+		Shortcuts: []handler.ShortcutConfig{
+			{Key: "v", TargetDir: "videos", Label: "视频"},
+			{Key: "p", TargetDir: filepath.Join(root, "png"), Label: "PNG"},
+		},
+		Rules: []handler.ClassifyRule{
+			{Shortcut: "v", Query: handler.MediaQuery{Types: []file.MediaType{file.MediaTypeVideo}}},
+			{Shortcut: "p", Query: handler.MediaQuery{Exts: []string{"png"}}},
+		},
+	}
+	data, err := json.Marshal(rules)
+	assert.Nil(t, err)
+	rulesPath := filepath.Join(t.TempDir(), "rules.json")
+	assert.Nil(t, os.WriteFile(rulesPath, data, 0644))
+
+	code, stdout, stderr := run(t, "classify", "-rules", rulesPath, "-dry-run", root)
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Contains(t, stdout, filepath.Join(root, "videos", "b.mp4"))
+	assert.ElementsMatch(t, []string{"a.jpg", "b.mp4", "c.png", "notes.txt"}, listNames(t, root))
+
+	code, stdout, stderr = run(t, "classify", "-rules", rulesPath, "-json", root)
+	assert.Equal(t, exitOK, code, stderr)
+	var moves []handler.ClassifyMove
+	assert.Nil(t, json.Unmarshal([]byte(stdout), &moves))
+	assert.Len(t, moves, 2)
+	assert.ElementsMatch(t, []string{"a.jpg", "notes.txt"}, listNames(t, root))
+	assert.FileExists(t, filepath.Join(root, "videos", "b.mp4"))
+	assert.FileExists(t, filepath.Join(root, "png", "c.png"))
+
+	rules.Rules[0].Shortcut = "x"
+	data, _ = json.Marshal(rules)
+	assert.Nil(t, os.WriteFile(rulesPath, data, 0644))
+	code, _, stderr = run(t, "classify", "-rules", rulesPath, root)
+	assert.Equal(t, exitError, code)
+	assert.Contains(t, stderr, "第 1 条规则无效")
+}
+
+func TestDedupe(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	root := t.TempDir()
+	img := image.NewGray(image.Rect(0, 0, 16, 16))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(i)
+	}
+	// e.jpg 重新编码，与其他图片相似但内容不同，不应被移除
+	for i, name := range []string{"b.jpg", "a.jpg", "sub/c.jpg", "D.JPG", "e.jpg"} {
+		path := filepath.Join(root, name)
+		assert.Nil(t, os.MkdirAll(filepath.Dir(path), 0755))
+		f, err := os.Create(path)
+		assert.Nil(t, err)
+		options := &jpeg.Options{Quality: jpeg.DefaultQuality}
+		if name == "e.jpg" {
+			options.Quality = 50
+		}
+		assert.Nil(t, jpeg.Encode(f, img, options))
+		assert.Nil(t, f.Close())
+		modTime := time.Now().Add(time.Duration(i-10) * time.Minute)
+		assert.Nil(t, os.Chtimes(path, modTime, modTime))
+	}
+
+	code, stdout, stderr := run(t, "dedupe", "-remove", "-json", root)
+	assert.Equal(t, exitOK, code, stderr)
+	var groups []DedupeGroup
+	assert.Nil(t, json.Unmarshal([]byte(stdout), &groups))
+	assert.Len(t, groups, 1)
+	// 保留修改时间最早的图片
+	assert.Equal(t, filepath.Join(root, "b.jpg"), groups[0].Keep)
+	assert.Len(t, groups[0].Removed, 3)
+	assert.FileExists(t, filepath.Join(root, "b.jpg"))
+	assert.FileExists(t, filepath.Join(root, "e.jpg"))
+	assert.FileExists(t, filepath.Join(root, ".delete", "a.jpg"))
+	assert.FileExists(t, filepath.Join(root, ".delete", "D.JPG"))
+	assert.FileExists(t, filepath.Join(root, "sub", ".delete", "c.jpg"))
+
+	// 再次执行时不处理 .delete 中的图片
+	code, stdout, stderr = run(t, "dedupe", "-remove", "-json", root)
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Nil(t, json.Unmarshal([]byte(stdout), &groups))
+	assert.Len(t, groups, 0)
+	assert.NoDirExists(t, filepath.Join(root, ".delete", ".delete"))
+	assert.FileExists(t, filepath.Join(root, "e.jpg"))
+}
+
+func TestServe(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	ctx, cancel := context.WithCancel(context.Background())
+	var stdout, stderr bytes.Buffer
+	done := make(chan int)
+	// 使用相对路径启动，目录应被转换为绝对路径
+	root := t.TempDir()
+	assert.Nil(t, os.MkdirAll(filepath.Join(root, "photos"), 0755))
+	assert.Nil(t, os.WriteFile(filepath.Join(root, "photos", "a.jpg"), []byte("a"), 0644))
+	t.Chdir(root)
+	go func() { done <- Run(ctx, []string{"serve", "-port", "0", "photos"}, &stdout, &stderr) }()
+
+	var info app.ServerInfo
+	assert.Eventually(t, func() bool {
+		data, err := os.ReadFile(app.ServerInfoPath())
+		return err == nil && json.Unmarshal(data, &info) == nil
+	}, 5*time.Second, 20*time.Millisecond)
+
+	get := func(path string, v any) int {
+		req, err := http.NewRequest(http.MethodGet, info.URL+"/api/v1/"+path, nil)
+		assert.Nil(t, err)
+		req.Header.Set("Authorization", "Bearer "+info.Token)
+		resp, err := http.DefaultClient.Do(req)
+		assert.Nil(t, err)
+		defer resp.Body.Close()
+		assert.Nil(t, json.NewDecoder(resp.Body).Decode(v))
+		return resp.StatusCode
+	}
+	var dir struct {
+		Dir string `json:"dir"`
+	}
+	assert.Equal(t, http.StatusOK, get("dir", &dir))
+	assert.Equal(t, filepath.Join(root, "photos"), dir.Dir)
+	assert.Eventually(t, func() bool {
+		var page handler.MediaPage
+		return get("media", &page) == http.StatusOK && page.Total == 1 && len(page.Items) == 1
+	}, 5*time.Second, 20*time.Millisecond)
+
+	cancel()
+	assert.Equal(t, exitOK, <-done)
+	assert.True(t, strings.Contains(stdout.String(), "服务已停止"))
+	assert.NoFileExists(t, app.ServerInfoPath())
+}
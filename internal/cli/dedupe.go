@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"media-app/internal/app"
+	"media-app/internal/handler"
+)
+
+// DedupeGroup 一组相同图片，Keep 为保留的图片（修改时间最早），Duplicates 为其余重复项
+type DedupeGroup struct {
+	Keep       string   `json:"keep"`
+	Duplicates []string `json:"duplicates"`
+	Removed    []string `json:"removed,omitempty"` // 已移入 .delete 的重复项
+	Errors     []string `json:"errors,omitempty"`
+}
+
+// runDedupe 查找目录（含子文件夹）中内容完全相同的图片，-remove 时将重复项移入所在目录的 .delete
+func runDedupe(_ context.Context, e *env, args []string) int {
+	fs := newFlagSet(e, "dedupe")
+	remove := fs.Bool("remove", false, "将重复项移入 .delete 文件夹")
+	asJSON := fs.Bool("json", false, "以 JSON 输出结果")
+	if err := fs.Parse(args); err != nil {
+		return parseError(err)
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	a := app.New(0)
+	a.SimilarHandler.SetSelectedDir(fs.Arg(0))
+	results := a.SimilarHandler.CalcSimilarity()
+
+	// 相似度分组基于感知哈希，连拍与重新编码的图片也会被分到一组，按大小与 SHA-256 确认内容相同
+	code := exitOK
+	groups := []DedupeGroup{}
+	var identical [][]handler.SimilarImage
+	for _, result := range results {
+		sets, err := identicalSets(result.Images)
+		if err != nil {
+			e.errorf("%v\n", err)
+			code = exitError
+		}
+		identical = append(identical, sets...)
+	}
+	for _, images := range identical {
+		group := dedupeGroup(images)
+		if *remove {
+			for _, path := range group.Duplicates {
+				if err := a.SimilarHandler.RemoveSimilarImage(path); err != nil {
+					group.Errors = append(group.Errors, path+": "+err.Error())
+					code = exitError
+					continue
+				}
+				group.Removed = append(group.Removed, path)
+			}
+		}
+		groups = append(groups, group)
+	}
+
+	if *asJSON {
+		if c := e.printJSON(groups); c != exitOK {
+			return c
+		}
+		return code
+	}
+	for i, group := range groups {
+		e.printf("第 %d 组\n  保留 %s\n", i+1, group.Keep)
+		for _, path := range group.Duplicates {
+			e.printf("  重复 %s\n", path)
+		}
+		for _, msg := range group.Errors {
+			e.errorf("  失败 %s\n", msg)
+		}
+	}
+	e.printf("共 %d 组相同图片\n", len(groups))
+	return code
+}
+
+// identicalSets 将相似的图片按文件大小与 SHA-256 拆分为内容完全相同的集合，只返回包含多张图片的集合。
+// 读取失败的图片不参与去重
+func identicalSets(images []handler.SimilarImage) ([][]handler.SimilarImage, error) {
+	type key struct {
+		size int64
+		sum  [sha256.Size]byte
+	}
+	var errs []error
+	var order []key
+	sets := make(map[key][]handler.SimilarImage)
+	for _, image := range images {
+		k, err := func() (key, error) {
+			f, err := os.Open(image.Path)
+			if err != nil {
+				return key{}, err
+			}
+			defer f.Close()
+			h := sha256.New()
+			n, err := io.Copy(h, f)
+			if err != nil {
+				return key{}, err
+			}
+			k := key{size: n}
+			copy(k.sum[:], h.Sum(nil))
+			return k, nil
+		}()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("读取 %s 失败: %w", image.Path, err))
+			continue
+		}
+		if _, ok := sets[k]; !ok {
+			order = append(order, k)
+		}
+		sets[k] = append(sets[k], image)
+	}
+
+	var result [][]handler.SimilarImage
+	for _, k := range order {
+		if len(sets[k]) > 1 {
+			result = append(result, sets[k])
+		}
+	}
+	return result, errors.Join(errs...)
+}
+
+// dedupeGroup 保留修改时间最早的图片，时间相同时按路径排序
+func dedupeGroup(images []handler.SimilarImage) DedupeGroup {
+	sorted := append([]handler.SimilarImage(nil), images...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if !sorted[i].ModTime.Equal(sorted[j].ModTime) {
+			return sorted[i].ModTime.Before(sorted[j].ModTime)
+		}
+		return sorted[i].Path < sorted[j].Path
+	})
+	group := DedupeGroup{Keep: sorted[0].Path}
+	for _, image := range sorted[1:] {
+		group.Duplicates = append(group.Duplicates, image.Path)
+	}
+	return group
+}
@@ -0,0 +1,185 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"media-app/pkg/file"
+	"media-app/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// batchWorkers 同时重命名的文件夹数
+const batchWorkers = 5
+
+// runFixNames 按修改时间将目录中的文件重命名为有序序号或按模板重命名，-batch 时处理各目录的直接子文件夹
+func runFixNames(ctx context.Context, e *env, args []string) int {
+	fs := newFlagSet(e, "fix-names")
+	width := fs.Int("width", 4, "序号位数，未指定 -template 时使用")
+	template := fs.String("template", "", "重命名模板，如 {date:2006-01-02}_{camera}_{seq:4}{ext}")
+	sortBy := fs.String("sort", string(file.RenameSortModTime), "编号排序方式：modTime、captureTime 或 name")
+	start := fs.Int("start", 1, "起始序号")
+	autoWidth := fs.Bool("auto-width", false, "序号位数不足时自动增加")
+	types := fs.String("types", "", "只重命名这些类型的文件，逗号分隔，如 image,video；其他文件保持不变")
+	separate := fs.Bool("separate", false, "每种类型单独编号")
+	batch := fs.Bool("batch", false, "处理目录下的各个子文件夹，而不是目录本身")
+	recursive := fs.Bool("recursive", false, "批量处理各级子文件夹，隐含 -batch")
+	depth := fs.Int("depth", 0, "-recursive 时的最大层级，直接子文件夹为第 1 层，0 表示不限制")
+	include := fs.String("include", "", "只处理名称匹配这些通配符的文件夹，逗号分隔，如 2024*,IMG_*")
+	exclude := fs.String("exclude", "", "跳过名称匹配这些通配符的文件夹及其子文件夹，逗号分隔")
+	dryRun := fs.Bool("dry-run", false, "只输出重命名计划，不修改文件")
+	resume := fs.Bool("resume", false, "继续执行目录中断的重命名")
+	revert := fs.Bool("revert", false, "撤销目录中断的重命名，恢复原文件名")
+	if err := fs.Parse(args); err != nil {
+		return parseError(err)
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitUsage
+	}
+	if *width <= 0 {
+		e.errorf("序号位数必须大于0，当前为%d\n", *width)
+		return exitUsage
+	}
+	options := file.RenameOptions{
+		Template:    *template,
+		SortBy:      file.RenameSort(*sortBy),
+		Start:       start,
+		AutoWidth:   *autoWidth,
+		SeparateSeq: *separate,
+	}
+	for _, t := range splitList(*types) {
+		options.Types = append(options.Types, file.MediaType(t))
+	}
+	dirOptions := file.BatchDirOptions{
+		Recursive: *recursive,
+		MaxDepth:  *depth,
+		Include:   splitList(*include),
+		Exclude:   splitList(*exclude),
+	}
+	*batch = *batch || *recursive
+	if options.Template == "" {
+		options.Template = fmt.Sprintf("{seq:%d}{ext}", *width)
+	}
+	if *resume || *revert {
+		if *resume && *revert {
+			e.errorf("-resume 与 -revert 不能同时使用\n")
+			return exitUsage
+		}
+		return recoverRenames(e, fs.Args(), *resume)
+	}
+
+	var dirs []string
+	for _, dir := range fs.Args() {
+		if !*batch {
+			dirs = append(dirs, dir)
+			continue
+		}
+		subDirs, err := file.BatchDirs(dir, dirOptions)
+		if err != nil {
+			e.errorf("%s: %v\n", dir, err)
+			return exitError
+		}
+		dirs = append(dirs, subDirs...)
+	}
+	if *dryRun {
+		return planRenames(ctx, e, dirs, options)
+	}
+
+	summary := file.BatchRename(ctx, dirs, options, batchWorkers, nil)
+	for _, result := range summary.Results {
+		if result.Error != "" {
+			logger.Error("修复文件名错误", zap.String("dir", result.Dir), zap.String("error", result.Error))
+			e.errorf("%s: %s\n", result.Dir, result.Error)
+			continue
+		}
+		if result.Skipped {
+			e.printf("%s: 跳过，没有需要重命名的文件\n", result.Dir)
+			continue
+		}
+		e.printf("%s: 完成，重命名 %d 个文件\n", result.Dir, result.Renamed)
+	}
+	if *batch {
+		e.printf("共 %d 个文件夹，完成 %d 个，跳过 %d 个，失败 %d 个\n",
+			summary.Total, summary.Done-summary.Failed-summary.Skipped, summary.Skipped, summary.Failed)
+	}
+	if summary.Canceled {
+		e.errorf("已取消\n")
+		return exitError
+	}
+	if summary.Failed > 0 {
+		return exitError
+	}
+	return exitOK
+}
+
+// planRenames 输出各目录的重命名计划，不修改文件
+func planRenames(ctx context.Context, e *env, dirs []string, options file.RenameOptions) int {
+	code := exitOK
+	for _, dir := range dirs {
+		if ctx.Err() != nil {
+			e.errorf("已取消\n")
+			return exitError
+		}
+		plan, err := file.PlanRename(dir, options)
+		if err != nil {
+			e.errorf("%s: %v\n", dir, err)
+			code = exitError
+			continue
+		}
+		printPlan(e, plan)
+	}
+	return code
+}
+
+// splitList 拆分逗号分隔的参数，忽略空项
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// printPlan 输出重命名计划
+func printPlan(e *env, plan *file.RenamePlan) {
+	e.printf("%s: %d 个文件需要重命名，%d 个无需修改\n", plan.Dir, len(plan.Ops), len(plan.Unchanged))
+	for _, op := range plan.Ops {
+		e.printf("  %s -> %s\n", op.From, op.To)
+	}
+	for _, skip := range plan.Skipped {
+		e.printf("  跳过 %s（%s）\n", skip.Name, skip.Reason)
+	}
+	for _, c := range plan.Conflicts {
+		e.printf("  冲突 %s -> %s：%s\n", c.From, c.To, c.Reason)
+	}
+}
+
+// recoverRenames 继续或撤销目录中断的重命名
+func recoverRenames(e *env, dirs []string, resume bool) int {
+	code := exitOK
+	for _, dir := range dirs {
+		journal, err := file.LoadJournal(dir)
+		if err == nil && journal == nil {
+			err = fmt.Errorf("没有未完成的重命名")
+		}
+		if err == nil {
+			if resume {
+				err = journal.Resume()
+			} else {
+				err = journal.Revert()
+			}
+		}
+		if err != nil {
+			e.errorf("%s: %v\n", dir, err)
+			code = exitError
+			continue
+		}
+		e.printf("%s: 完成\n", dir)
+	}
+	return code
+}
@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"context"
+
+	"media-app/internal/api"
+	"media-app/internal/app"
+	"media-app/internal/server"
+)
+
+// runServe 无界面运行文件服务与 REST API，地址与访问令牌写入配置目录的 server.json
+func runServe(ctx context.Context, e *env, args []string) int {
+	fs := newFlagSet(e, "serve")
+	port := fs.Int("port", 8080, "首选端口，被占用时自动选择其他端口")
+	if err := fs.Parse(args); err != nil {
+		return parseError(err)
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	a := app.New(*port)
+	a.HttpServer.Handle(api.Prefix, api.New(a))
+	if err := app.StartServer(a); err != nil {
+		e.errorf("%v\n", err)
+		return exitError
+	}
+	defer a.Shutdown(context.Background())
+
+	if fs.NArg() == 1 {
+		if err := a.OpenDir(fs.Arg(0)); err != nil {
+			e.errorf("%v\n", err)
+			return exitError
+		}
+	}
+	e.printf("服务已启动: %s%s\n", a.HttpServer.BaseURL(), api.Prefix)
+	e.printf("访问令牌: %s（也可从 %s 读取）\n", a.HttpServer.Token(), app.ServerInfoPath())
+	e.printf("事件流: %s%s\n", a.HttpServer.BaseURL(), server.EventsPath)
+
+	<-ctx.Done()
+	e.printf("服务已停止\n")
+	return exitOK
+}
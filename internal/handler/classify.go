@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"media-app/pkg/file"
+	"media-app/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// ClassifyRule 自动分类规则，媒体满足 Query 时移动到 Shortcut 对应的目标文件夹
+type ClassifyRule struct {
+	Shortcut string     `json:"shortcut"` // 快捷键
+	Query    MediaQuery `json:"query"`    // 匹配条件，零值匹配全部媒体
+}
+
+// ClassifyRules 自动分类规则集，按顺序匹配，第一条满足的规则生效
+type ClassifyRules struct {
+	Shortcuts []ShortcutConfig `json:"shortcuts,omitempty"` // 快捷键配置，为空时使用已保存的配置
+	Rules     []ClassifyRule   `json:"rules"`
+}
+
+// ClassifyMove 一次分类移动
+type ClassifyMove struct {
+	Source   string `json:"source"`
+	Target   string `json:"target"`
+	Shortcut string `json:"shortcut"`
+	Error    string `json:"error,omitempty"`
+}
+
+// LoadClassifyRules 读取 JSON 格式的分类规则文件
+func LoadClassifyRules(path string) (ClassifyRules, error) {
+	var rules ClassifyRules
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return rules, fmt.Errorf("读取分类规则失败: %w", err)
+	}
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return rules, fmt.Errorf("解析分类规则失败: %w", err)
+	}
+	if len(rules.Rules) == 0 {
+		return rules, fmt.Errorf("分类规则为空: %s", path)
+	}
+	return rules, nil
+}
+
+// Classify 按规则将 dir 下的媒体文件（不含子文件夹）移动到对应的目标文件夹，
+// dryRun 时只返回计划的移动，不修改文件。单个文件失败不影响其他文件
+func (sh *ShortcutHandler) Classify(dir string, rules ClassifyRules, dryRun bool) ([]ClassifyMove, error) {
+	shortcuts := rules.Shortcuts
+	if len(shortcuts) == 0 {
+		shortcuts = sh.GetShortcuts()
+	}
+	type compiledRule struct {
+		shortcut ShortcutConfig
+		filter   *mediaFilter
+	}
+	compiled := make([]compiledRule, 0, len(rules.Rules))
+	for i, rule := range rules.Rules {
+		sc, err := findShortcut(shortcuts, rule.Shortcut)
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 条规则无效: %w", i+1, err)
+		}
+		filter, err := newMediaFilter(rule.Query)
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 条规则无效: %w", i+1, err)
+		}
+		compiled = append(compiled, compiledRule{shortcut: sc, filter: filter})
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("读取目录失败: %w", err)
+	}
+
+	sh.mux.Lock()
+	defer sh.mux.Unlock()
+
+	var moves []ClassifyMove
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		mediaType := file.GetFileTypeByExt(entry.Name())
+		if mediaType != file.MediaTypeImage && mediaType != file.MediaTypeVideo {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		media := MediaInfo{
+			Path:    filepath.Join(dir, entry.Name()),
+			Name:    entry.Name(),
+			Size:    info.Size(),
+			Type:    mediaType,
+			ModTime: info.ModTime(),
+		}
+		for _, rule := range compiled {
+			if !rule.filter.match(&media) {
+				continue
+			}
+			move := ClassifyMove{Source: media.Path, Shortcut: rule.shortcut.Key}
+			if dryRun {
+				move.Target = sh.resolveConflict(filepath.Join(sh.resolveTargetDir(media.Path, rule.shortcut.TargetDir), media.Name))
+			} else if record, err := sh.moveFile(media.Path, rule.shortcut); err != nil {
+				move.Error = err.Error()
+			} else {
+				move.Target = record.TargetPath
+			}
+			moves = append(moves, move)
+			break
+		}
+	}
+	if !dryRun {
+		sh.syncTargetRoots(shortcuts)
+	}
+	logger.Info("按规则分类完成", zap.String("dir", dir), zap.Int("moves", len(moves)), zap.Bool("dryRun", dryRun))
+	return moves, nil
+}
@@ -0,0 +1,25 @@
+package handler
+
+import (
+	"os"
+	"path/filepath"
+
+	"media-app/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// ConfigDir 返回应用配置目录 ~/.media-app，不存在时创建
+func ConfigDir() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		logger.Error("获取用户目录失败", zap.Error(err))
+		homeDir = "."
+	}
+
+	configDir := filepath.Join(homeDir, ".media-app")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		logger.Error("创建配置目录失败", zap.Error(err))
+	}
+	return configDir
+}
@@ -0,0 +1,278 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"media-app/pkg/event"
+	"media-app/pkg/export"
+	"media-app/pkg/file"
+	"media-app/pkg/logger"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+	"go.uber.org/zap"
+)
+
+// ExportPrefix 导出下载的 HTTP 路径前缀，完整路径为 /_export/<导出 ID>
+const ExportPrefix = "/_export/"
+
+// exportEventInterval 导出进度事件的最小间隔
+const exportEventInterval = 200 * time.Millisecond
+
+// exportTTL 导出任务创建后未开始下载时的有效期，过期后移除
+const exportTTL = 10 * time.Minute
+
+// ExportRequest 导出内容，Paths 与 Dir 至少指定一个
+type ExportRequest struct {
+	Name  string   `json:"name"`  // ZIP 文件名
+	Paths []string `json:"paths"` // 选中的文件
+	Dir   string   `json:"dir"`   // 导出目录下的全部媒体文件，如分类的目标文件夹
+}
+
+// ExportInfo 已创建的导出任务
+type ExportInfo struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`  // ZIP 文件名
+	URL   string `json:"url"`   // 下载地址，开始下载后失效
+	Files int    `json:"files"` // 文件数
+}
+
+// ExportStatus 导出进度，通过 export-progress 事件发送
+type ExportStatus struct {
+	ID string `json:"id"`
+	export.Progress
+	Done     bool   `json:"done"`            // 是否已结束
+	Canceled bool   `json:"canceled"`        // 是否被取消
+	Error    string `json:"error,omitempty"` // 失败原因
+}
+
+// exportJob 导出任务，每个任务只能执行一次
+type exportJob struct {
+	info      ExportInfo
+	files     []string
+	createdAt time.Time
+	started   bool
+	cancel    context.CancelFunc
+}
+
+// expired 任务是否未开始且已超过有效期
+func (j *exportJob) expired(now time.Time) bool {
+	return !j.started && now.Sub(j.createdAt) > exportTTL
+}
+
+// ExportHandler ZIP 导出处理器，ZIP 直接写入下载响应或用户选择的文件，不生成临时文件
+type ExportHandler struct {
+	ctx    context.Context
+	urls   *URLBuilder
+	events *event.Bus
+	mux    sync.Mutex
+	jobs   map[string]*exportJob
+}
+
+// NewExportHandler 创建导出处理器
+func NewExportHandler(urls *URLBuilder, events *event.Bus) *ExportHandler {
+	return &ExportHandler{
+		urls:   urls,
+		events: events,
+		jobs:   make(map[string]*exportJob),
+	}
+}
+
+// SetContext 设置 wails 上下文
+func (eh *ExportHandler) SetContext(ctx context.Context) {
+	eh.ctx = ctx
+}
+
+// emit 通过事件总线发送事件，未设置事件总线时忽略
+func (eh *ExportHandler) emit(name string, data any) {
+	eh.events.Emit(name, data)
+}
+
+// Prepare 创建导出任务，返回的下载地址在开始下载前有效，未开始的任务 exportTTL 后过期
+func (eh *ExportHandler) Prepare(req ExportRequest) (ExportInfo, error) {
+	files, err := exportFiles(req)
+	if err != nil {
+		return ExportInfo{}, err
+	}
+	id := newToken()
+	job := &exportJob{
+		info: ExportInfo{
+			ID:    id,
+			Name:  exportName(req.Name),
+			URL:   eh.urls.ExportURL(id),
+			Files: len(files),
+		},
+		files:     files,
+		createdAt: time.Now(),
+	}
+
+	eh.mux.Lock()
+	eh.removeExpired()
+	eh.jobs[id] = job
+	eh.mux.Unlock()
+	logger.Info("创建导出任务", zap.String("id", id), zap.String("name", job.info.Name), zap.Int("files", len(files)))
+	return job.info, nil
+}
+
+// ExportToFile 弹出保存对话框并在后台将 ZIP 写入所选文件，用户取消选择时返回空的 ExportInfo
+func (eh *ExportHandler) ExportToFile(req ExportRequest) (ExportInfo, error) {
+	info, err := eh.Prepare(req)
+	if err != nil {
+		return ExportInfo{}, err
+	}
+	dest, err := runtime.SaveFileDialog(eh.ctx, runtime.SaveDialogOptions{
+		Title:           "导出 ZIP",
+		DefaultFilename: info.Name,
+		Filters:         []runtime.FileFilter{{DisplayName: "ZIP 文件", Pattern: "*.zip"}},
+	})
+	if err != nil || dest == "" {
+		eh.discard(info.ID)
+		if err != nil {
+			return ExportInfo{}, fmt.Errorf("选择保存位置失败: %w", err)
+		}
+		return ExportInfo{}, nil
+	}
+
+	f, err := os.Create(dest)
+	if err != nil {
+		eh.discard(info.ID)
+		return ExportInfo{}, fmt.Errorf("创建文件失败: %w", err)
+	}
+	go func() {
+		err := eh.Stream(context.Background(), info.ID, f)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		// 失败或取消时删除不完整的文件
+		if err != nil {
+			_ = os.Remove(dest)
+		}
+	}()
+	return info, nil
+}
+
+// Info 返回尚未开始的导出任务
+func (eh *ExportHandler) Info(id string) (ExportInfo, bool) {
+	eh.mux.Lock()
+	defer eh.mux.Unlock()
+	job, ok := eh.jobs[id]
+	if !ok || job.started || job.expired(time.Now()) {
+		return ExportInfo{}, false
+	}
+	return job.info, true
+}
+
+// Stream 执行导出任务，将 ZIP 写入 w；ctx 取消或调用 Cancel 时停止
+func (eh *ExportHandler) Stream(ctx context.Context, id string, w io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	eh.mux.Lock()
+	job, ok := eh.jobs[id]
+	if !ok || job.started || job.expired(time.Now()) {
+		eh.mux.Unlock()
+		return fmt.Errorf("导出任务不存在或已开始: %s", id)
+	}
+	job.started = true
+	job.cancel = cancel
+	eh.mux.Unlock()
+	defer eh.discard(id)
+
+	status := ExportStatus{ID: id}
+	var lastEmit time.Time
+	err := export.Zip(ctx, w, job.files, func(p export.Progress) {
+		status.Progress = p
+		if time.Since(lastEmit) >= exportEventInterval {
+			lastEmit = time.Now()
+			eh.emit("export-progress", status)
+		}
+	})
+
+	status.Done = true
+	switch {
+	case errors.Is(err, context.Canceled):
+		status.Canceled = true
+		logger.Info("导出已取消", zap.String("id", id))
+	case err != nil:
+		status.Error = err.Error()
+		logger.Error("导出失败", zap.String("id", id), zap.Error(err))
+	default:
+		logger.Info("导出完成", zap.String("id", id), zap.Int("files", status.Files), zap.Int64("bytes", status.Bytes))
+	}
+	eh.emit("export-progress", status)
+	return err
+}
+
+// Cancel 取消导出任务，尚未开始的任务直接移除
+func (eh *ExportHandler) Cancel(id string) {
+	eh.mux.Lock()
+	defer eh.mux.Unlock()
+	job, ok := eh.jobs[id]
+	if !ok {
+		return
+	}
+	if job.cancel != nil {
+		job.cancel()
+	}
+	delete(eh.jobs, id)
+}
+
+// discard 移除导出任务
+func (eh *ExportHandler) discard(id string) {
+	eh.mux.Lock()
+	defer eh.mux.Unlock()
+	delete(eh.jobs, id)
+}
+
+// removeExpired 移除过期未开始的导出任务，调用方需持有 mux
+func (eh *ExportHandler) removeExpired() {
+	now := time.Now()
+	for id, job := range eh.jobs {
+		if job.expired(now) {
+			delete(eh.jobs, id)
+			logger.Info("导出任务已过期", zap.String("id", id))
+		}
+	}
+}
+
+// exportFiles 展开导出内容，Dir 只包含其中的媒体文件（不含子文件夹与隐藏文件）
+func exportFiles(req ExportRequest) ([]string, error) {
+	files := append([]string(nil), req.Paths...)
+	if req.Dir != "" {
+		entries, err := os.ReadDir(req.Dir)
+		if err != nil {
+			return nil, fmt.Errorf("读取目录失败: %w", err)
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+				continue
+			}
+			if mediaType := file.GetFileTypeByExt(entry.Name()); mediaType == file.MediaTypeImage || mediaType == file.MediaTypeVideo {
+				files = append(files, filepath.Join(req.Dir, entry.Name()))
+			}
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("没有要导出的文件")
+	}
+	return files, nil
+}
+
+// exportName 生成 ZIP 文件名，去掉路径部分
+func exportName(name string) string {
+	name = strings.TrimSpace(filepath.Base(name))
+	if strings.EqualFold(filepath.Ext(name), ".zip") {
+		name = name[:len(name)-len(".zip")]
+	}
+	if name == "" || name == "." || name == string(filepath.Separator) {
+		name = "export"
+	}
+	return name + ".zip"
+}
@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportExpiry(t *testing.T) {
+	dir := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "a.jpg"), []byte("a"), 0644))
+	eh := NewExportHandler(NewURLBuilder(8080), nil)
+
+	old, err := eh.Prepare(ExportRequest{Dir: dir})
+	assert.Nil(t, err)
+	_, ok := eh.Info(old.ID)
+	assert.True(t, ok)
+
+	// 超过有效期未下载的任务不能再开始，并在创建新任务时移除
+	eh.jobs[old.ID].createdAt = time.Now().Add(-exportTTL - time.Second)
+	_, ok = eh.Info(old.ID)
+	assert.False(t, ok)
+	assert.NotNil(t, eh.Stream(context.Background(), old.ID, &bytes.Buffer{}))
+
+	info, err := eh.Prepare(ExportRequest{Paths: []string{filepath.Join(dir, "a.jpg")}})
+	assert.Nil(t, err)
+	assert.NotContains(t, eh.jobs, old.ID)
+	assert.Contains(t, eh.jobs, info.ID)
+
+	var buf bytes.Buffer
+	assert.Nil(t, eh.Stream(context.Background(), info.ID, &buf))
+	assert.NotZero(t, buf.Len())
+	assert.Empty(t, eh.jobs)
+}
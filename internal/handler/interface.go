@@ -1,6 +1,9 @@
 package handler
 
-import "context"
+import (
+	"context"
+	"path/filepath"
+)
 
 // Handler 处理器
 type Handler interface {
@@ -9,3 +12,14 @@ type Handler interface {
 	GetSelectedDir() string
 	SetSelectedDir(dir string)
 }
+
+// absDir 将选中的目录转换为绝对路径，文件服务与相对路径计算都依赖绝对路径；空目录表示未选择，保持为空
+func absDir(dir string) string {
+	if dir == "" {
+		return dir
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		return abs
+	}
+	return dir
+}
@@ -2,44 +2,110 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io/fs"
+	"media-app/pkg/exif"
 	"media-app/pkg/file"
 	"os"
 	"path/filepath"
-	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	"media-app/pkg/event"
+	"media-app/pkg/index"
 	"media-app/pkg/logger"
+	"media-app/pkg/thumb"
+	"media-app/pkg/video"
+	"media-app/pkg/watcher"
 
-	"github.com/wailsapp/wails/v2/pkg/runtime"
 	"go.uber.org/zap"
 )
 
+const (
+	mediaChunkSize     = 500                    // 每批次推送的媒体数量
+	mediaChunkInterval = 200 * time.Millisecond // 扫描较慢时的最长推送间隔
+	maxPageSize        = 1000                   // 分页查询的最大条数
+)
+
 // MediaHandler handles media file operations
 type MediaHandler struct {
-	dir  string
-	mux  sync.Mutex
-	ctx  context.Context
-	port int
+	dir     string
+	mux     sync.Mutex
+	ctx     context.Context
+	urls    *URLBuilder
+	events  *event.Bus
+	options ScanOptions
+	store   *index.Store // 媒体索引存储
+	index   *index.Index // 所选目录的索引
+
+	sort     SortOptions // 当前目录的排序选项
+	sortGen  int         // 排序选项的修改次数，丢弃过期的后台排序结果
+	sortPath string      // 各目录排序选项的保存路径
+	sortMux  sync.Mutex
+
+	medias     []MediaInfo        // 最近一次扫描结果，已排序
+	scanID     int                // 当前扫描批次
+	scanCancel context.CancelFunc // 取消正在进行的扫描
+	watcher    *watcher.Watcher   // 当前目录的变化监听
+	syncMux    sync.Mutex         // 串行化目录变化同步
+
+	batch    *batchJob // 最近一次批量重命名任务
+	batchMux sync.Mutex
 }
 
 // MediaInfo represents information about a media file
 type MediaInfo struct {
-	Path    string         `json:"path"`
-	Name    string         `json:"name"`
-	Size    int64          `json:"size"`
-	Url     string         `json:"url"`
-	Type    file.MediaType `json:"type"`
-	ModTime time.Time      `json:"modTime"`
+	Path     string         `json:"path"`
+	Name     string         `json:"name"`
+	Folder   string         `json:"folder"` // 相对于所选目录的文件夹，根目录为空
+	Size     int64          `json:"size"`
+	Url      string         `json:"url"`
+	ThumbUrl string         `json:"thumbUrl,omitempty"` // 缩略图 URL，仅图片
+	Type     file.MediaType `json:"type"`
+	ModTime  time.Time      `json:"modTime"`
+
+	Width       int         `json:"width,omitempty"`       // 像素宽度，未读取时为 0
+	Height      int         `json:"height,omitempty"`      // 像素高度，未读取时为 0
+	CaptureTime *time.Time  `json:"captureTime,omitempty"` // EXIF 拍摄时间
+	Exif        *exif.Meta  `json:"exif,omitempty"`        // EXIF 元数据，读取后才有
+	Video       *video.Meta `json:"video,omitempty"`       // 视频元数据，读取后才有
+}
+
+// MediaPage 媒体列表分页结果
+type MediaPage struct {
+	Items  []MediaInfo `json:"items"`
+	Offset int         `json:"offset"`
+	Limit  int         `json:"limit"`
+	Total  int         `json:"total"`
 }
 
-// NewMediaHandler creates a new MediaHandler instance
-func NewMediaHandler(port int) *MediaHandler {
+// MediaChunk 扫描过程中增量推送的媒体列表
+type MediaChunk struct {
+	ScanID int         `json:"scanId"` // 扫描批次，用于丢弃过期的推送
+	Items  []MediaInfo `json:"items"`  // 本批次的媒体，按扫描顺序
+	Offset int         `json:"offset"` // 本批次在扫描结果中的起始位置
+	Done   bool        `json:"done"`   // 扫描是否已完成
+	Total  int         `json:"total"`  // 扫描完成时的媒体总数
+}
+
+// ScanOptions 目录扫描选项
+type ScanOptions struct {
+	Recursive   bool     `json:"recursive"`   // 是否递归扫描子目录
+	MaxDepth    int      `json:"maxDepth"`    // 最大递归深度，0 表示不限制
+	ExcludeDirs []string `json:"excludeDirs"` // 额外排除的目录名
+}
+
+// NewMediaHandler creates a new MediaHandler instance,
+// the sort options of each directory are saved under configDir
+func NewMediaHandler(urls *URLBuilder, store *index.Store, events *event.Bus, configDir string) *MediaHandler {
 	return &MediaHandler{
-		port: port,
+		urls:     urls,
+		events:   events,
+		store:    store,
+		sort:     defaultSortOptions,
+		sortPath: filepath.Join(configDir, "sort.json"),
 	}
 }
 
@@ -57,30 +123,99 @@ func (mh *MediaHandler) GetSelectedDir() string {
 func (mh *MediaHandler) SetSelectedDir(dir string) {
 	mh.mux.Lock()
 	defer mh.mux.Unlock()
+	dir = absDir(dir)
+	mh.urls.ReplaceRoot(mh.dir, dir)
 	mh.dir = dir
+	mh.index = mh.store.Open(dir)
+	mh.sort = mh.loadSortOptions(dir)
 	logger.Info("目录已选择", zap.String("dir", dir))
 }
 
+// GetScanOptions returns the scan options
+func (mh *MediaHandler) GetScanOptions() ScanOptions {
+	mh.mux.Lock()
+	defer mh.mux.Unlock()
+	return mh.options
+}
+
+// SetScanOptions sets the scan options
+func (mh *MediaHandler) SetScanOptions(options ScanOptions) {
+	mh.mux.Lock()
+	defer mh.mux.Unlock()
+	if options.MaxDepth < 0 {
+		options.MaxDepth = 0
+	}
+	mh.options = options
+	logger.Info("扫描选项已更新", zap.Bool("recursive", options.Recursive), zap.Int("maxDepth", options.MaxDepth))
+}
+
+// skipDir 判断扫描时是否跳过子目录
+func (o ScanOptions) skipDir(name string, depth int) bool {
+	if !o.Recursive || file.FilterDir(name) {
+		return true
+	}
+	if o.MaxDepth > 0 && depth > o.MaxDepth {
+		return true
+	}
+	for _, exclude := range o.ExcludeDirs {
+		if strings.EqualFold(exclude, name) {
+			return true
+		}
+	}
+	return false
+}
+
 // GetMediaFiles scans the given directory and returns a list of media file paths
 func (mh *MediaHandler) GetMediaFiles() []MediaInfo {
 	var medias []MediaInfo
+	dir := mh.GetSelectedDir()
+	options := mh.GetScanOptions()
+	err := mh.walkMedia(context.Background(), dir, options, func(media MediaInfo) {
+		medias = append(medias, media)
+	})
+	if err != nil {
+		logger.Error("扫描目录失败", zap.String("dir", dir), zap.Error(err))
+	}
+	mh.remember(mh.currentIndex(), dir, options, medias)
+	mh.sortMedias(medias, mh.GetSortOptions())
+	return medias
+}
 
-	dirStat, err := os.Stat(mh.dir)
-	if err != nil || !dirStat.IsDir() {
-		logger.Error("无效的目录", zap.String("dir", mh.dir), zap.Error(err))
-		return medias
+// walkMedia 遍历目录，每找到一个媒体文件调用一次 fn
+func (mh *MediaHandler) walkMedia(ctx context.Context, dir string, options ScanOptions, fn func(MediaInfo)) error {
+	dirStat, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("目录不存在或无法访问: %w", err)
+	}
+	if !dirStat.IsDir() {
+		return fmt.Errorf("指定路径不是文件夹: %s", dir)
 	}
 
-	err = filepath.WalkDir(mh.dir, func(path string, d fs.DirEntry, err error) error {
+	count := 0
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
 		if err != nil {
 			logger.Error("遍历文件失败", zap.String("path", path), zap.Error(err))
 			return nil
 		}
 		if d.IsDir() {
-			if path == mh.dir {
+			if path == dir {
 				return nil
 			}
-			return filepath.SkipDir
+			relDir, err := filepath.Rel(dir, path)
+			if err != nil {
+				return filepath.SkipDir
+			}
+			depth := len(strings.Split(relDir, string(filepath.Separator)))
+			if options.skipDir(d.Name(), depth) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if file.FilterFile(d.Name()) {
+			return nil
 		}
 
 		abs, err := filepath.Abs(path)
@@ -94,7 +229,7 @@ func (mh *MediaHandler) GetMediaFiles() []MediaInfo {
 			logger.Error("获取文件信息失败", zap.String("path", abs), zap.Error(err))
 			return nil
 		}
-		relPath, err := filepath.Rel(mh.dir, abs)
+		relPath, err := filepath.Rel(dir, abs)
 		if err != nil {
 			logger.Error("获取相对路径失败", zap.String("path", abs), zap.Error(err))
 			return nil
@@ -105,120 +240,207 @@ func (mh *MediaHandler) GetMediaFiles() []MediaInfo {
 			logger.Error("非图片视频", zap.String("abs", abs), zap.Any("mediaType", mediaType))
 			return nil
 		}
-		urlPath := strings.ReplaceAll(relPath, string(filepath.Separator), "/")
-		// 添加修改时间戳防止浏览器缓存
-		modTimeUnix := fileInfo.ModTime().Unix()
-		medias = append(medias, MediaInfo{
-			Path:    abs,
-			Name:    d.Name(),
-			Size:    fileInfo.Size(),
-			Url:     fmt.Sprintf("http://localhost:%d/%s?t=%d", mh.port, urlPath, modTimeUnix),
-			Type:    file.GetFileTypeByExt(relPath),
-			ModTime: fileInfo.ModTime(),
-		})
+		fn(mh.newMediaInfo(dir, relPath, mediaType, fileInfo.Size(), fileInfo.ModTime()))
+		count++
 		return nil
 	})
-
 	if err != nil {
-		logger.Error("遍历目录失败", zap.String("dir", mh.dir), zap.Error(err))
+		return fmt.Errorf("遍历目录失败: %w", err)
 	}
 
-	logger.Info("扫描完成", zap.Int("count", len(medias)), zap.String("dir", mh.dir))
-
-	// 最新的文件在前面
-	sort.Slice(medias, func(i, j int) bool {
-		return medias[i].ModTime.After(medias[j].ModTime)
-	})
-	return medias
-}
-
-// SendMediaFiles sends the media files to the frontend
-func (mh *MediaHandler) SendMediaFiles(mediaInfos []MediaInfo, fileCount int, filepath string) {
-	runtime.EventsEmit(mh.ctx, "media-list", mediaInfos)
-	runtime.EventsEmit(mh.ctx, "media-count", fileCount)
-	runtime.EventsEmit(mh.ctx, "selected-dir", filepath)
-	logger.Debug("已发送媒体列表到前端", zap.Int("size", len(mediaInfos)), zap.Int("count", fileCount))
+	logger.Info("扫描完成", zap.Int("count", count), zap.String("dir", dir))
+	return nil
 }
 
-// FixMediaFilename fix the media filename
-func (mh *MediaHandler) FixMediaFilename() {
-	selected := mh.GetSelectedDir()
-	err := file.WithOrderly(selected, 4)
-	if err != nil {
-		logger.Error("重排序文件失败", zap.Error(err))
-		return
+// newMediaInfo 构建媒体信息，relPath 为相对于所选目录 dir 的路径
+func (mh *MediaHandler) newMediaInfo(dir, relPath string, mediaType file.MediaType, size int64, modTime time.Time) MediaInfo {
+	abs := filepath.Join(dir, relPath)
+	folder := filepath.ToSlash(filepath.Dir(relPath))
+	if folder == "." {
+		folder = ""
 	}
-	files, err := file.CountFiles(selected)
-	if err != nil {
-		logger.Error("读取文件数量失败", zap.Error(err))
-		files = -1
+	media := MediaInfo{
+		Path:    abs,
+		Name:    filepath.Base(abs),
+		Folder:  folder,
+		Size:    size,
+		Url:     mh.urls.FileURL(dir, relPath, modTime),
+		Type:    mediaType,
+		ModTime: modTime,
 	}
-	mh.SendMediaFiles(mh.GetMediaFiles(), files, selected)
+	if mediaType == file.MediaTypeImage {
+		media.ThumbUrl = mh.urls.ThumbURL(dir, relPath, thumb.DefaultSize, modTime)
+	}
+	return media
 }
 
-// BatchFixMediaFilename batch fix the media filename
-func (mh *MediaHandler) BatchFixMediaFilename() {
+// LoadMediaFiles scans the selected directory in the background and streams
+// "media-list-chunk" events while the walk is still running
+func (mh *MediaHandler) LoadMediaFiles(fileCount int) {
 	dir := mh.GetSelectedDir()
-	dirStat, err := os.Stat(dir)
-	if err != nil {
-		logger.Error("目录不存在或无法访问", zap.String("dir", dir), zap.Error(err))
-		return
-	}
-	if !dirStat.IsDir() {
-		logger.Error("指定路径不是文件夹", zap.String("dir", dir))
+	if dir == "" {
 		return
 	}
+	options := mh.GetScanOptions()
 
-	// 读取目录下所有文件，收集文件信息
-	entries, err := os.ReadDir(dir)
-	if err != nil {
-		logger.Error("读取目录失败", zap.String("dir", dir), zap.Error(err))
-		return
+	mh.mux.Lock()
+	if mh.scanCancel != nil {
+		mh.scanCancel()
 	}
-
-	// 过滤出目录
-	var dirs []os.DirEntry
-	for _, entry := range entries {
-		if entry.IsDir() {
-			dirs = append(dirs, entry)
+	mh.stopWatch()
+	ctx, cancel := context.WithCancel(context.Background())
+	mh.scanCancel = cancel
+	mh.scanID++
+	scanID := mh.scanID
+	ix := mh.index
+	mh.mux.Unlock()
+
+	mh.emit("media-count", fileCount)
+	mh.emit("selected-dir", dir)
+
+	go func() {
+		defer cancel()
+
+		// 索引中已有记录时立即展示，再在后台校验目录变化
+		if cached := mh.cachedMedias(ix, dir, options); len(cached) > 0 {
+			mh.sortMedias(cached, mh.GetSortOptions())
+			mh.mux.Lock()
+			if mh.scanID != scanID {
+				mh.mux.Unlock()
+				return
+			}
+			mh.medias = cached
+			mh.mux.Unlock()
+			mh.emit("media-list-chunk", MediaChunk{ScanID: scanID, Items: []MediaInfo{}, Done: true, Total: len(cached)})
+			logger.Debug("已从索引加载媒体列表", zap.String("dir", dir), zap.Int("size", len(cached)))
+
+			mh.syncChanges(dir, options, scanID)
+			mh.watch(dir, options, scanID)
+			return
 		}
-	}
 
-	logger.Info("开始批量修复文件名", zap.Int("目录数量", len(dirs)))
+		var medias []MediaInfo
+		chunk := make([]MediaInfo, 0, mediaChunkSize)
+		offset := 0
+		lastFlush := time.Now()
+		flush := func() {
+			if len(chunk) == 0 {
+				return
+			}
+			mh.emit("media-list-chunk", MediaChunk{ScanID: scanID, Items: chunk, Offset: offset})
+			offset += len(chunk)
+			chunk = make([]MediaInfo, 0, mediaChunkSize)
+			lastFlush = time.Now()
+		}
 
-	sem := make(chan struct{}, 5)
-	wg := &sync.WaitGroup{}
-	wg.Add(len(dirs))
+		err := mh.walkMedia(ctx, dir, options, func(media MediaInfo) {
+			medias = append(medias, media)
+			chunk = append(chunk, media)
+			if len(chunk) >= mediaChunkSize || time.Since(lastFlush) >= mediaChunkInterval {
+				flush()
+			}
+		})
+		if ctx.Err() != nil {
+			logger.Debug("扫描已取消", zap.String("dir", dir), zap.Int("scanId", scanID))
+			return
+		}
+		if err != nil {
+			logger.Error("扫描目录失败", zap.String("dir", dir), zap.Error(err))
+		}
+		flush()
+		mh.remember(ix, dir, options, medias)
+		mh.sortMedias(medias, mh.GetSortOptions())
+
+		mh.mux.Lock()
+		if mh.scanID != scanID {
+			mh.mux.Unlock()
+			return
+		}
+		mh.medias = medias
+		mh.mux.Unlock()
+		mh.watch(dir, options, scanID)
+
+		// 扫描完成后前端通过 GetMediaPage 按排序结果分页拉取
+		mh.emit("media-list-chunk", MediaChunk{ScanID: scanID, Items: []MediaInfo{}, Offset: offset, Done: true, Total: len(medias)})
+		logger.Debug("已发送媒体列表到前端", zap.Int("size", len(medias)), zap.Int("count", fileCount))
+	}()
+}
 
-	for _, entry := range dirs {
-		sem <- struct{}{}
-		go func(e os.DirEntry) {
-			defer wg.Done()
-			defer func() { <-sem }()
+// GetMediaPage returns a page of the last scan result in display order
+func (mh *MediaHandler) GetMediaPage(offset, limit int) MediaPage {
+	mh.mux.Lock()
+	defer mh.mux.Unlock()
+	return paginate(mh.medias, offset, limit)
+}
 
-			sub := filepath.Join(dir, e.Name())
-			logger.Debug("处理子目录", zap.String("dir", sub))
+// paginate 截取 medias 中的一页
+func paginate(medias []MediaInfo, offset, limit int) MediaPage {
+	total := len(medias)
+	if offset < 0 {
+		offset = 0
+	}
+	if limit <= 0 || limit > maxPageSize {
+		limit = maxPageSize
+	}
+	page := MediaPage{Items: []MediaInfo{}, Offset: offset, Limit: limit, Total: total}
+	if offset >= total {
+		return page
+	}
+	end := min(offset+limit, total)
+	page.Items = append(page.Items, medias[offset:end]...)
+	return page
+}
 
-			err := file.WithOrderly(sub, 4)
-			if err != nil {
-				logger.Error("修复文件名错误", zap.String("dir", sub), zap.Error(err))
-			} else {
-				logger.Debug("子目录处理完成", zap.String("dir", sub))
-			}
-		}(entry)
+// forget 从扫描结果中移除已被移走的文件
+func (mh *MediaHandler) forget(path string) {
+	mh.mux.Lock()
+	defer mh.mux.Unlock()
+	for i, media := range mh.medias {
+		if media.Path == path {
+			mh.medias = append(mh.medias[:i:i], mh.medias[i+1:]...)
+			return
+		}
 	}
+}
 
-	wg.Wait()
-	logger.Info("批量修复文件名完成")
+// emit 通过事件总线发送事件，未设置事件总线时忽略
+func (mh *MediaHandler) emit(name string, data any) {
+	mh.events.Emit(name, data)
 }
 
-// RemoveMedia 删除媒体资源
+// RefreshMediaFiles rescans the selected directory and sends the result to the frontend
+func (mh *MediaHandler) RefreshMediaFiles() {
+	selected := mh.GetSelectedDir()
+	if selected == "" {
+		return
+	}
+	files, err := file.CountFiles(selected)
+	if err != nil {
+		logger.Error("读取文件数量失败", zap.Error(err))
+		files = -1
+	}
+	mh.LoadMediaFiles(files)
+}
+
+// ErrOutsideRoot 文件不在所选目录或文件服务已注册的根目录内
+var ErrOutsideRoot = errors.New("文件不在允许访问的目录内")
+
+// RemoveMedia 删除媒体资源，将文件移入同目录的 .delete 文件夹。
+// 文件必须位于所选目录或文件服务已注册的根目录内
 func (mh *MediaHandler) RemoveMedia(filePath string) error {
 	_, err := os.Stat(filePath)
 	if err != nil {
 		logger.Error("源文件不存在或无法访问", zap.String("filePath", filePath), zap.Error(err))
 		return err
 	}
+	roots := []string{mh.GetSelectedDir()}
+	for _, root := range mh.urls.Roots() {
+		roots = append(roots, root.Dir)
+	}
+	if !withinRoots(roots, filePath) {
+		logger.Warn("拒绝删除目录外的文件", zap.String("filePath", filePath))
+		return fmt.Errorf("%w：%s", ErrOutsideRoot, filePath)
+	}
 	srcDir := filepath.Dir(filePath)
 
 	deleteDir := filepath.Join(srcDir, ".delete")
@@ -234,6 +456,8 @@ func (mh *MediaHandler) RemoveMedia(filePath string) error {
 	err = file.RenameFile(filePath, targetFilePath, true, 100)
 	if err != nil {
 		logger.Error("移动到 .delete 目录失败", zap.String("filePath", filePath), zap.Error(err))
+		return fmt.Errorf("移动到 .delete 目录失败：%w", err)
 	}
+	mh.forget(filePath)
 	return nil
 }
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"media-app/pkg/file"
+	"media-app/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// batchWorkers 批量重命名同时处理的文件夹数
+const batchWorkers = 5
+
+// BatchJob 批量重命名任务，进度通过 batch-rename-progress 事件发送
+type BatchJob struct {
+	ID         string             `json:"id"`
+	Dir        string             `json:"dir"`
+	State      JobState           `json:"state"`
+	StartedAt  time.Time          `json:"startedAt"`
+	FinishedAt *time.Time         `json:"finishedAt,omitempty"`
+	Progress   file.BatchProgress `json:"progress"`
+	Summary    *file.BatchSummary `json:"summary,omitempty"` // 结束后的结果
+}
+
+// BatchRenameRequest 批量重命名请求：各文件夹的重命名选项与文件夹的选择方式
+type BatchRenameRequest struct {
+	file.RenameOptions
+	file.BatchDirOptions
+}
+
+// batchJob 执行中的批量重命名任务
+type batchJob struct {
+	BatchJob
+	cancel context.CancelFunc
+}
+
+// StartBatchRename 在后台重命名所选目录下的子文件夹，req.Recursive 时包括各级子文件夹，
+// 同一时间只允许一个任务
+func (mh *MediaHandler) StartBatchRename(req BatchRenameRequest) (BatchJob, error) {
+	dir := mh.GetSelectedDir()
+	if dir == "" {
+		return BatchJob{}, fmt.Errorf("未选择文件夹")
+	}
+	dirs, err := file.BatchDirs(dir, req.BatchDirOptions)
+	if err != nil {
+		return BatchJob{}, err
+	}
+
+	mh.batchMux.Lock()
+	if mh.batch != nil && mh.batch.State == JobRunning {
+		mh.batchMux.Unlock()
+		return BatchJob{}, fmt.Errorf("已有批量重命名任务在执行")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &batchJob{
+		BatchJob: BatchJob{
+			ID:        newToken()[:12],
+			Dir:       dir,
+			State:     JobRunning,
+			StartedAt: time.Now(),
+			Progress:  file.BatchProgress{Total: len(dirs)},
+		},
+		cancel: cancel,
+	}
+	mh.batch = job
+	snapshot := job.BatchJob
+	// 进度事件均在持有 batchMux 时发送，保证事件顺序与任务状态的更新顺序一致
+	mh.events.Emit("batch-rename-progress", snapshot)
+	mh.batchMux.Unlock()
+
+	logger.Info("开始批量重命名", zap.String("id", job.ID), zap.String("dir", dir), zap.Int("目录数量", len(dirs)))
+	go func() {
+		defer cancel()
+		summary := file.BatchRename(ctx, dirs, req.RenameOptions, batchWorkers, func(p file.BatchProgress) {
+			mh.batchMux.Lock()
+			defer mh.batchMux.Unlock()
+			job.Progress = p
+			mh.events.Emit("batch-rename-progress", job.BatchJob)
+		})
+		for _, result := range summary.Results {
+			if result.Error != "" {
+				logger.Error("修复文件名错误", zap.String("dir", result.Dir), zap.String("error", result.Error))
+			}
+		}
+
+		finishedAt := time.Now()
+		mh.batchMux.Lock()
+		job.State = JobDone
+		job.FinishedAt = &finishedAt
+		job.Progress = summary.BatchProgress
+		job.Summary = &summary
+		mh.events.Emit("batch-rename-progress", job.BatchJob)
+		mh.batchMux.Unlock()
+
+		logger.Info("批量重命名完成", zap.String("id", job.ID), zap.Int("done", summary.Done),
+			zap.Int("failed", summary.Failed), zap.Int("skipped", summary.Skipped), zap.Bool("canceled", summary.Canceled))
+		// 递归扫描时子文件夹的文件在列表中，需要重新扫描
+		if summary.Done > summary.Failed+summary.Skipped && mh.GetSelectedDir() == job.Dir && mh.GetScanOptions().Recursive {
+			mh.RefreshMediaFiles()
+		}
+	}()
+	return snapshot, nil
+}
+
+// CancelBatchRename 取消执行中的批量重命名，正在处理的文件夹会执行完成
+func (mh *MediaHandler) CancelBatchRename(id string) bool {
+	mh.batchMux.Lock()
+	defer mh.batchMux.Unlock()
+	if mh.batch == nil || mh.batch.ID != id || mh.batch.State != JobRunning {
+		return false
+	}
+	logger.Info("取消批量重命名", zap.String("id", id))
+	mh.batch.cancel()
+	return true
+}
+
+// BatchRenameJob 返回最近一次批量重命名任务，没有任务时返回 false
+func (mh *MediaHandler) BatchRenameJob() (BatchJob, bool) {
+	mh.batchMux.Lock()
+	defer mh.batchMux.Unlock()
+	if mh.batch == nil {
+		return BatchJob{}, false
+	}
+	return mh.batch.BatchJob, true
+}
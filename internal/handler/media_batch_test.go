@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"media-app/pkg/event"
+	"media-app/pkg/file"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStartBatchRename(t *testing.T) {
+	file.SetJournalDir(t.TempDir())
+	root := t.TempDir()
+	writeFiles(t, root, "a/x.jpg", "a/y.jpg", "b/z.mp4", "b/raw/w.jpg", "b/2024/v.jpg")
+	assert.Nil(t, os.Mkdir(filepath.Join(root, "empty"), 0755))
+
+	bus := event.NewBus()
+	events, cancel := bus.Subscribe(16, "batch-rename-progress")
+	defer cancel()
+	mh := NewMediaHandler(NewURLBuilder(8080), nil, bus, t.TempDir())
+	_, err := mh.StartBatchRename(BatchRenameRequest{})
+	assert.NotNil(t, err)
+
+	mh.SetSelectedDir(root)
+	job, err := mh.StartBatchRename(BatchRenameRequest{
+		RenameOptions:   file.RenameOptions{Template: "{seq:2}{ext}"},
+		BatchDirOptions: file.BatchDirOptions{Recursive: true, Exclude: []string{"raw"}},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, JobRunning, job.State)
+	assert.Equal(t, 4, job.Progress.Total)
+
+	// 进度事件按顺序到达，已处理数不减少
+	var last BatchJob
+	for last.State != JobDone {
+		select {
+		case e := <-events:
+			current := e.Data.(BatchJob)
+			assert.GreaterOrEqual(t, current.Progress.Done, last.Progress.Done)
+			last = current
+		case <-time.After(5 * time.Second):
+			t.Fatal("批量重命名未完成")
+		}
+	}
+	assert.Equal(t, job.ID, last.ID)
+	assert.Equal(t, 4, last.Summary.Done)
+	assert.Equal(t, 0, last.Summary.Failed)
+	assert.Equal(t, 1, last.Summary.Skipped)
+	assert.FileExists(t, filepath.Join(root, "a", "02.jpg"))
+	assert.FileExists(t, filepath.Join(root, "b", "2024", "01.jpg"))
+	assert.FileExists(t, filepath.Join(root, "b", "raw", "w.jpg"))
+	assert.False(t, mh.CancelBatchRename(job.ID))
+
+	latest, ok := mh.BatchRenameJob()
+	assert.True(t, ok)
+	assert.Equal(t, JobDone, latest.State)
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"fmt"
+
+	"media-app/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// GetMediaDetail returns the media of the current list with its metadata,
+// reading EXIF or video metadata on demand when it has not been read yet
+func (mh *MediaHandler) GetMediaDetail(path string) (MediaInfo, error) {
+	mh.mux.Lock()
+	ix := mh.index
+	var media *MediaInfo
+	for i := range mh.medias {
+		if mh.medias[i].Path == path {
+			found := mh.medias[i]
+			media = &found
+			break
+		}
+	}
+	mh.mux.Unlock()
+
+	if media == nil {
+		return MediaInfo{}, fmt.Errorf("媒体不在当前列表中: %s", path)
+	}
+	if !probeable(media) || media.Exif != nil || media.Video != nil {
+		return *media, nil
+	}
+
+	enrichMedia(ix, media)
+	if err := ix.Save(); err != nil {
+		logger.Error("保存媒体索引失败", zap.String("path", path), zap.Error(err))
+	}
+
+	// 写回当前列表，避免重复读取
+	mh.mux.Lock()
+	for i := range mh.medias {
+		if mh.medias[i].Path == path && mh.medias[i].ModTime.Equal(media.ModTime) {
+			mh.medias[i] = *media
+			break
+		}
+	}
+	mh.mux.Unlock()
+	return *media, nil
+}
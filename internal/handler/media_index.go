@@ -0,0 +1,193 @@
+package handler
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"media-app/pkg/exif"
+	"media-app/pkg/file"
+	"media-app/pkg/imaging"
+	"media-app/pkg/index"
+	"media-app/pkg/logger"
+	"media-app/pkg/video"
+
+	"go.uber.org/zap"
+)
+
+// enrichWorkers 读取 EXIF 与尺寸时的并发数
+const enrichWorkers = 8
+
+// currentIndex 返回所选目录的索引
+func (mh *MediaHandler) currentIndex() *index.Index {
+	mh.mux.Lock()
+	defer mh.mux.Unlock()
+	return mh.index
+}
+
+// includesFolder 相对文件夹是否在扫描范围内
+func (o ScanOptions) includesFolder(folder string) bool {
+	if folder == "" {
+		return true
+	}
+	for i, name := range strings.Split(folder, "/") {
+		if o.skipDir(name, i+1) {
+			return false
+		}
+	}
+	return true
+}
+
+// cachedMedias 从索引还原扫描范围内的媒体列表，索引为空时返回 nil
+func (mh *MediaHandler) cachedMedias(ix *index.Index, dir string, options ScanOptions) []MediaInfo {
+	entries := ix.Entries()
+	if len(entries) == 0 {
+		return nil
+	}
+
+	medias := make([]MediaInfo, 0, len(entries))
+	for _, e := range entries {
+		if !options.includesFolder(e.Folder) {
+			continue
+		}
+		relPath := filepath.Join(filepath.FromSlash(e.Folder), e.Name)
+		media := mh.newMediaInfo(dir, relPath, e.Type, e.Size, e.ModTime)
+		applyEntry(&media, e)
+		medias = append(medias, media)
+	}
+	logger.Debug("读取媒体索引", zap.String("dir", dir), zap.Int("entries", len(entries)), zap.Int("size", len(medias)))
+	return medias
+}
+
+// applyEntry 将索引中已读取的尺寸、拍摄时间与元数据写入媒体信息
+func applyEntry(media *MediaInfo, e index.Entry) {
+	if !e.Probed {
+		return
+	}
+	media.Width, media.Height = e.Width, e.Height
+	media.Exif, media.Video = e.Exif, e.Video
+	if e.Exif != nil && !e.Exif.CaptureTime.IsZero() {
+		captureTime := e.Exif.CaptureTime
+		media.CaptureTime = &captureTime
+	}
+	if e.Video != nil && !e.Video.CreationTime.IsZero() {
+		creationTime := e.Video.CreationTime
+		media.CaptureTime = &creationTime
+	}
+}
+
+// remember 将完整扫描结果写入索引，并删除扫描范围内已不存在的记录
+func (mh *MediaHandler) remember(ix *index.Index, dir string, options ScanOptions, medias []MediaInfo) {
+	if ix == nil {
+		return
+	}
+	seen := make(map[string]bool, len(medias))
+	for i := range medias {
+		media := &medias[i]
+		seen[media.Path] = true
+		ix.Update(media.Path, media.Size, media.ModTime, func(e *index.Entry) {
+			e.Name, e.Folder, e.Type = media.Name, media.Folder, media.Type
+		})
+		if e, ok := ix.Get(media.Path, media.Size, media.ModTime); ok && media.Width == 0 {
+			applyEntry(media, e)
+		}
+	}
+	ix.Prune(func(path string, e index.Entry) bool {
+		return seen[path] || !options.includesFolder(e.Folder)
+	})
+	if err := ix.Save(); err != nil {
+		logger.Error("保存媒体索引失败", zap.String("dir", dir), zap.Error(err))
+	}
+}
+
+// enrichMedias 为图片与视频补充拍摄时间、尺寸等元数据，优先使用索引中的记录
+func (mh *MediaHandler) enrichMedias(medias []MediaInfo) {
+	ix := mh.currentIndex()
+
+	jobs := make(chan *MediaInfo)
+	var wg sync.WaitGroup
+	wg.Add(enrichWorkers)
+	for i := 0; i < enrichWorkers; i++ {
+		go func() {
+			defer wg.Done()
+			for media := range jobs {
+				enrichMedia(ix, media)
+			}
+		}()
+	}
+	for i := range medias {
+		if probeable(&medias[i]) && medias[i].Width == 0 {
+			jobs <- &medias[i]
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err := ix.Save(); err != nil {
+		logger.Error("保存媒体索引失败", zap.Error(err))
+	}
+}
+
+// probeable 是否可以读取元数据
+func probeable(media *MediaInfo) bool {
+	return media.Type == file.MediaTypeImage || media.Type == file.MediaTypeVideo
+}
+
+// enrichMedia 读取单个媒体的元数据，并记录到索引
+func enrichMedia(ix *index.Index, media *MediaInfo) {
+	if e, ok := ix.Get(media.Path, media.Size, media.ModTime); ok && e.Probed {
+		applyEntry(media, e)
+		return
+	}
+	if media.Type == file.MediaTypeVideo {
+		enrichVideo(ix, media)
+		return
+	}
+
+	if width, height, err := imaging.Size(media.Path); err == nil {
+		media.Width, media.Height = width, height
+	} else {
+		logger.Debug("读取图片尺寸失败", zap.String("path", media.Path), zap.Error(err))
+	}
+	meta, err := exif.ReadFile(media.Path)
+	if err == nil {
+		media.Exif = meta
+		if !meta.CaptureTime.IsZero() {
+			media.CaptureTime = &meta.CaptureTime
+		}
+		// 宽高按摆正后的方向记录
+		if imaging.SwapsAxes(meta.Orientation) {
+			media.Width, media.Height = media.Height, media.Width
+		}
+	}
+
+	ix.Update(media.Path, media.Size, media.ModTime, func(e *index.Entry) {
+		e.Name, e.Folder, e.Type = media.Name, media.Folder, media.Type
+		e.Probed = true
+		e.Width, e.Height = media.Width, media.Height
+		if err == nil {
+			e.Exif = meta
+		}
+	})
+}
+
+// enrichVideo 读取视频的时长、尺寸与创建时间，并记录到索引
+func enrichVideo(ix *index.Index, media *MediaInfo) {
+	meta, err := video.ReadFile(media.Path)
+	if err == nil {
+		media.Video = meta
+		media.Width, media.Height = meta.Width, meta.Height
+		if !meta.CreationTime.IsZero() {
+			media.CaptureTime = &meta.CreationTime
+		}
+	} else {
+		logger.Debug("读取视频元数据失败", zap.String("path", media.Path), zap.Error(err))
+	}
+
+	ix.Update(media.Path, media.Size, media.ModTime, func(e *index.Entry) {
+		e.Name, e.Folder, e.Type = media.Name, media.Folder, media.Type
+		e.Probed = true
+		e.Width, e.Height = media.Width, media.Height
+		e.Video = meta
+	})
+}
@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"media-app/pkg/index"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCachedMedias(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, "a.jpg", "b.mp4", "sub/c.jpg")
+
+	mh := NewMediaHandler(NewURLBuilder(8080), index.NewStore(t.TempDir()), nil, t.TempDir())
+	mh.SetSelectedDir(root)
+	mh.SetScanOptions(ScanOptions{Recursive: true})
+	assert.Len(t, mh.GetMediaFiles(), 3)
+
+	names := func(medias []MediaInfo) []string {
+		var result []string
+		for _, media := range medias {
+			result = append(result, media.Name)
+		}
+		return result
+	}
+
+	ix := mh.currentIndex()
+	assert.Equal(t, 3, ix.Len())
+	cached := mh.cachedMedias(ix, root, ScanOptions{Recursive: true})
+	sortMediaList(cached, SortOptions{Field: SortByName})
+	assert.Equal(t, []string{"a.jpg", "b.mp4", "c.jpg"}, names(cached))
+	assert.Equal(t, "sub", cached[2].Folder)
+	assert.Equal(t, mh.newMediaInfo(root, filepath.Join("sub", "c.jpg"),
+		cached[2].Type, cached[2].Size, cached[2].ModTime), cached[2])
+
+	// 非递归时子文件夹的记录不在范围内，但仍保留在索引中
+	cached = mh.cachedMedias(ix, root, ScanOptions{})
+	assert.Len(t, cached, 2)
+
+	assert.Nil(t, os.Remove(filepath.Join(root, "a.jpg")))
+	mh.SetScanOptions(ScanOptions{})
+	assert.Len(t, mh.GetMediaFiles(), 1)
+	assert.Equal(t, 2, ix.Len())
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"media-app/pkg/file"
+)
+
+// MediaQuery 媒体过滤条件，零值字段不参与过滤
+type MediaQuery struct {
+	Types     []file.MediaType `json:"types"`     // 媒体类型
+	Exts      []string         `json:"exts"`      // 后缀名，如 .jpg，不区分大小写
+	MinSize   int64            `json:"minSize"`   // 最小文件大小（字节）
+	MaxSize   int64            `json:"maxSize"`   // 最大文件大小（字节），0 表示不限制
+	ModAfter  *time.Time       `json:"modAfter"`  // 修改时间不早于
+	ModBefore *time.Time       `json:"modBefore"` // 修改时间早于
+	Name      string           `json:"name"`      // 文件名通配符（含 * ? [ 时）或子串，不区分大小写
+}
+
+// mediaFilter 预处理后的过滤条件
+type mediaFilter struct {
+	query MediaQuery
+	exts  map[string]bool
+	name  string
+	glob  bool
+}
+
+// newMediaFilter 校验并预处理过滤条件
+func newMediaFilter(query MediaQuery) (*mediaFilter, error) {
+	if query.MaxSize > 0 && query.MinSize > query.MaxSize {
+		return nil, fmt.Errorf("文件大小范围无效: %d > %d", query.MinSize, query.MaxSize)
+	}
+	if query.ModAfter != nil && query.ModBefore != nil && !query.ModAfter.Before(*query.ModBefore) {
+		return nil, fmt.Errorf("修改时间范围无效")
+	}
+
+	f := &mediaFilter{query: query, name: strings.ToLower(strings.TrimSpace(query.Name))}
+	if len(query.Exts) > 0 {
+		f.exts = make(map[string]bool, len(query.Exts))
+		for _, ext := range query.Exts {
+			ext = strings.ToLower(strings.TrimSpace(ext))
+			if ext == "" {
+				continue
+			}
+			if !strings.HasPrefix(ext, ".") {
+				ext = "." + ext
+			}
+			f.exts[ext] = true
+		}
+	}
+	if strings.ContainsAny(f.name, "*?[") {
+		if _, err := filepath.Match(f.name, ""); err != nil {
+			return nil, fmt.Errorf("无效的文件名通配符 %s: %w", query.Name, err)
+		}
+		f.glob = true
+	}
+	return f, nil
+}
+
+// match 媒体是否满足过滤条件
+func (f *mediaFilter) match(media *MediaInfo) bool {
+	q := f.query
+	if len(q.Types) > 0 && !slices.Contains(q.Types, media.Type) {
+		return false
+	}
+	if len(f.exts) > 0 && !f.exts[strings.ToLower(filepath.Ext(media.Name))] {
+		return false
+	}
+	if media.Size < q.MinSize || (q.MaxSize > 0 && media.Size > q.MaxSize) {
+		return false
+	}
+	if q.ModAfter != nil && media.ModTime.Before(*q.ModAfter) {
+		return false
+	}
+	if q.ModBefore != nil && !media.ModTime.Before(*q.ModBefore) {
+		return false
+	}
+	if f.name != "" {
+		name := strings.ToLower(media.Name)
+		if f.glob {
+			if ok, _ := filepath.Match(f.name, name); !ok {
+				return false
+			}
+		} else if !strings.Contains(name, f.name) {
+			return false
+		}
+	}
+	return true
+}
+
+// QueryMedia filters the last scan result and returns a page of matches,
+// keeping the current sort order
+func (mh *MediaHandler) QueryMedia(query MediaQuery, offset, limit int) (MediaPage, error) {
+	filter, err := newMediaFilter(query)
+	if err != nil {
+		return MediaPage{}, err
+	}
+
+	mh.mux.Lock()
+	defer mh.mux.Unlock()
+
+	var matches []MediaInfo
+	for i := range mh.medias {
+		if filter.match(&mh.medias[i]) {
+			matches = append(matches, mh.medias[i])
+		}
+	}
+	return paginate(matches, offset, limit), nil
+}
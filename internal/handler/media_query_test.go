@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"testing"
+	"time"
+
+	"media-app/pkg/file"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryMedia(t *testing.T) {
+	now := time.Now()
+	mh := NewMediaHandler(NewURLBuilder(8080), nil, nil, t.TempDir())
+	mh.medias = []MediaInfo{
+		{Name: "IMG_0001.JPG", Size: 100, Type: "image", ModTime: now.Add(-48 * time.Hour)},
+		{Name: "IMG_0002.png", Size: 2000, Type: "image", ModTime: now.Add(-time.Hour)},
+		{Name: "clip.mp4", Size: 5000, Type: "video", ModTime: now},
+		{Name: "holiday.jpg", Size: 300, Type: "image", ModTime: now},
+	}
+	names := func(page MediaPage) []string {
+		var result []string
+		for _, media := range page.Items {
+			result = append(result, media.Name)
+		}
+		return result
+	}
+
+	page, err := mh.QueryMedia(MediaQuery{Types: []file.MediaType{file.MediaTypeVideo}}, 0, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"clip.mp4"}, names(page))
+
+	page, err = mh.QueryMedia(MediaQuery{Exts: []string{"jpg"}}, 0, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"IMG_0001.JPG", "holiday.jpg"}, names(page))
+
+	page, err = mh.QueryMedia(MediaQuery{MinSize: 200, MaxSize: 3000}, 0, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"IMG_0002.png", "holiday.jpg"}, names(page))
+
+	after := now.Add(-2 * time.Hour)
+	page, err = mh.QueryMedia(MediaQuery{ModAfter: &after, Name: "img_*"}, 0, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"IMG_0002.png"}, names(page))
+
+	page, err = mh.QueryMedia(MediaQuery{Name: "DAY"}, 0, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"holiday.jpg"}, names(page))
+	assert.Equal(t, 1, page.Total)
+
+	_, err = mh.QueryMedia(MediaQuery{Name: "[img"}, 0, 10)
+	assert.NotNil(t, err)
+	_, err = mh.QueryMedia(MediaQuery{MinSize: 10, MaxSize: 1}, 0, 10)
+	assert.NotNil(t, err)
+}
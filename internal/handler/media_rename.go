@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"fmt"
+
+	"media-app/pkg/file"
+	"media-app/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// PlanFixMediaFilename 预览所选目录的文件名修复，按修改时间编号为 0001 格式，不修改文件
+func (mh *MediaHandler) PlanFixMediaFilename() (file.RenamePlan, error) {
+	return mh.PlanRenameMedia(file.RenameOptions{})
+}
+
+// PlanRenameMedia 按模板预览所选目录的重命名，不修改文件
+func (mh *MediaHandler) PlanRenameMedia(opts file.RenameOptions) (file.RenamePlan, error) {
+	dir := mh.GetSelectedDir()
+	if dir == "" {
+		return file.RenamePlan{}, fmt.Errorf("未选择文件夹")
+	}
+	plan, err := file.PlanRename(dir, opts)
+	if err != nil {
+		return file.RenamePlan{}, err
+	}
+	return *plan, nil
+}
+
+// ApplyRenamePlan 严格按预览的计划重命名，完成后重新扫描所选目录
+func (mh *MediaHandler) ApplyRenamePlan(plan file.RenamePlan) error {
+	if err := plan.Apply(); err != nil {
+		logger.Error("重命名失败", zap.String("dir", plan.Dir), zap.Error(err))
+		return err
+	}
+	logger.Info("重命名完成", zap.String("dir", plan.Dir), zap.Int("files", len(plan.Ops)))
+	mh.RefreshMediaFiles()
+	return nil
+}
+
+// InterruptedRenames 返回上次未完成的重命名，应用启动时提示用户继续或撤销
+func (mh *MediaHandler) InterruptedRenames() ([]file.RenameJournal, error) {
+	return file.PendingJournals()
+}
+
+// ResumeRename 继续执行目录中断的重命名
+func (mh *MediaHandler) ResumeRename(dir string) error {
+	return mh.recoverRename(dir, (*file.RenameJournal).Resume)
+}
+
+// RevertRename 撤销目录中断的重命名，恢复原文件名
+func (mh *MediaHandler) RevertRename(dir string) error {
+	return mh.recoverRename(dir, (*file.RenameJournal).Revert)
+}
+
+// recoverRename 读取目录的重命名日志并继续或撤销，目录为所选目录时重新扫描
+func (mh *MediaHandler) recoverRename(dir string, action func(*file.RenameJournal) error) error {
+	journal, err := file.LoadJournal(dir)
+	if err != nil {
+		return err
+	}
+	if journal == nil {
+		return fmt.Errorf("目录没有未完成的重命名: %s", dir)
+	}
+	if err := action(journal); err != nil {
+		logger.Error("恢复中断的重命名失败", zap.String("dir", dir), zap.Error(err))
+		return err
+	}
+	logger.Info("已恢复中断的重命名", zap.String("dir", dir), zap.String("phase", string(journal.Phase)))
+	if dir == mh.GetSelectedDir() {
+		mh.RefreshMediaFiles()
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package handler
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"media-app/pkg/file"
+	"media-app/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// SortField 媒体排序字段
+type SortField string
+
+const (
+	SortByModTime     SortField = "modTime"     // 修改时间
+	SortByName        SortField = "name"        // 文件名（自然排序）
+	SortBySize        SortField = "size"        // 文件大小
+	SortByType        SortField = "type"        // 媒体类型
+	SortByCaptureTime SortField = "captureTime" // EXIF 拍摄时间
+	SortByDimensions  SortField = "dimensions"  // 像素尺寸
+	SortByDuration    SortField = "duration"    // 视频时长
+)
+
+// SortOptions 媒体排序选项
+type SortOptions struct {
+	Field SortField `json:"field"`
+	Desc  bool      `json:"desc"`
+}
+
+// defaultSortOptions 默认排序：最新的文件在前面
+var defaultSortOptions = SortOptions{Field: SortByModTime, Desc: true}
+
+// valid 排序字段是否有效
+func (o SortOptions) valid() bool {
+	switch o.Field {
+	case SortByModTime, SortByName, SortBySize, SortByType, SortByCaptureTime, SortByDimensions, SortByDuration:
+		return true
+	}
+	return false
+}
+
+// GetSortOptions returns the sort options of the selected directory
+func (mh *MediaHandler) GetSortOptions() SortOptions {
+	mh.mux.Lock()
+	defer mh.mux.Unlock()
+	return mh.sort
+}
+
+// SetSortOptions changes the sort order, remembers it for the selected directory
+// and re-sorts the last scan result
+func (mh *MediaHandler) SetSortOptions(options SortOptions) error {
+	if !options.valid() {
+		return fmt.Errorf("无效的排序字段: %s", options.Field)
+	}
+
+	mh.mux.Lock()
+	mh.sort = options
+	mh.sortGen++
+	gen := mh.sortGen
+	dir := mh.dir
+	scanID := mh.scanID
+	medias := append([]MediaInfo(nil), mh.medias...)
+	mh.mux.Unlock()
+
+	if dir != "" {
+		if err := mh.saveSortOptions(dir, options); err != nil {
+			logger.Error("保存排序选项失败", zap.String("dir", dir), zap.Error(err))
+		}
+	}
+	logger.Info("排序选项已更新", zap.String("field", string(options.Field)), zap.Bool("desc", options.Desc))
+
+	go func() {
+		mh.sortMedias(medias, options)
+
+		// 排序期间重新扫描或再次修改了排序选项时丢弃结果，避免旧的排序覆盖新的
+		mh.mux.Lock()
+		if mh.scanID != scanID || mh.sortGen != gen {
+			mh.mux.Unlock()
+			return
+		}
+		mh.medias = medias
+		mh.mux.Unlock()
+
+		// 通知前端按新的顺序重新分页拉取
+		mh.emit("media-list-chunk", MediaChunk{ScanID: scanID, Items: []MediaInfo{}, Offset: len(medias), Done: true, Total: len(medias)})
+	}()
+	return nil
+}
+
+// sortMedias 排序前按需补充拍摄时间、尺寸与时长
+func (mh *MediaHandler) sortMedias(medias []MediaInfo, options SortOptions) {
+	if options.Field == SortByCaptureTime || options.Field == SortByDimensions || options.Field == SortByDuration {
+		mh.enrichMedias(medias)
+	}
+	sortMediaList(medias, options)
+}
+
+// sortMediaList 按文件夹分组，组内按排序选项排序，相同时按文件名排序
+func sortMediaList(medias []MediaInfo, options SortOptions) {
+	sort.SliceStable(medias, func(i, j int) bool {
+		a, b := &medias[i], &medias[j]
+		if a.Folder != b.Folder {
+			return a.Folder < b.Folder
+		}
+		c := compareMedia(a, b, options.Field)
+		if options.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c < 0
+		}
+		return file.NaturalLess(a.Name, b.Name)
+	})
+}
+
+// compareMedia 按排序字段比较两个媒体
+func compareMedia(a, b *MediaInfo, field SortField) int {
+	switch field {
+	case SortByName:
+		switch {
+		case file.NaturalLess(a.Name, b.Name):
+			return -1
+		case file.NaturalLess(b.Name, a.Name):
+			return 1
+		}
+		return 0
+	case SortBySize:
+		return cmp.Compare(a.Size, b.Size)
+	case SortByType:
+		return cmp.Compare(a.Type, b.Type)
+	case SortByCaptureTime:
+		return a.takenAt().Compare(b.takenAt())
+	case SortByDimensions:
+		return cmp.Compare(a.Width*a.Height, b.Width*b.Height)
+	case SortByDuration:
+		return cmp.Compare(a.duration(), b.duration())
+	default:
+		return a.ModTime.Compare(b.ModTime)
+	}
+}
+
+// takenAt 拍摄时间，缺失时使用修改时间
+func (m *MediaInfo) takenAt() time.Time {
+	if m.CaptureTime != nil {
+		return *m.CaptureTime
+	}
+	return m.ModTime
+}
+
+// duration 视频时长，非视频或未读取时为 0
+func (m *MediaInfo) duration() float64 {
+	if m.Video == nil {
+		return 0
+	}
+	return m.Video.Duration
+}
+
+// loadSortOptions 读取 dir 记住的排序选项
+func (mh *MediaHandler) loadSortOptions(dir string) SortOptions {
+	settings, err := mh.readSortSettings()
+	if err != nil {
+		logger.Error("读取排序选项失败", zap.Error(err))
+		return defaultSortOptions
+	}
+	if options, ok := settings[dir]; ok && options.valid() {
+		return options
+	}
+	return defaultSortOptions
+}
+
+// saveSortOptions 记住 dir 的排序选项
+func (mh *MediaHandler) saveSortOptions(dir string, options SortOptions) error {
+	mh.sortMux.Lock()
+	defer mh.sortMux.Unlock()
+
+	settings, err := mh.readSortSettings()
+	if err != nil {
+		settings = map[string]SortOptions{}
+	}
+	settings[dir] = options
+
+	data, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化排序选项失败: %w", err)
+	}
+	if err := os.WriteFile(mh.sortPath, data, 0644); err != nil {
+		return fmt.Errorf("写入排序选项失败: %w", err)
+	}
+	return nil
+}
+
+// readSortSettings 读取所有目录的排序选项
+func (mh *MediaHandler) readSortSettings() (map[string]SortOptions, error) {
+	settings := map[string]SortOptions{}
+	data, err := os.ReadFile(mh.sortPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return settings, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"media-app/pkg/logger"
+	"media-app/pkg/video"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	cfg := logger.DefaultConfig()
+	cfg.FileName = filepath.Join(os.TempDir(), "media-app-test", "app.log")
+	cfg.OutputConsole = false
+	if err := logger.Init(cfg); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// writeFiles 在 root 下创建测试文件
+func writeFiles(t *testing.T, root string, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		assert.Nil(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.Nil(t, os.WriteFile(path, []byte(name), 0644))
+	}
+}
+
+func TestGetMediaFilesRecursive(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root,
+		"a.jpg", "note.txt",
+		"2024/event/b.jpg", "2024/event/c.mp4",
+		"2024/event/deep/d.png",
+		"2024/.delete/e.jpg", ".star/f.jpg", "skip/g.jpg",
+	)
+
+	mh := NewMediaHandler(NewURLBuilder(8080), nil, nil, t.TempDir())
+	mh.SetSelectedDir(root)
+
+	medias := mh.GetMediaFiles()
+	assert.Len(t, medias, 1)
+	assert.Equal(t, "", medias[0].Folder)
+
+	mh.SetScanOptions(ScanOptions{Recursive: true, ExcludeDirs: []string{"skip"}})
+	folders := map[string]int{}
+	for _, media := range mh.GetMediaFiles() {
+		folders[media.Folder]++
+	}
+	assert.Equal(t, map[string]int{"": 1, "2024/event": 2, "2024/event/deep": 1}, folders)
+
+	mh.SetScanOptions(ScanOptions{Recursive: true, MaxDepth: 2})
+	folders = map[string]int{}
+	for _, media := range mh.GetMediaFiles() {
+		folders[media.Folder]++
+	}
+	assert.Equal(t, map[string]int{"": 1, "2024/event": 2, "skip": 1}, folders)
+}
+
+func TestGetMediaPage(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, "1.jpg", "2.jpg", "3.jpg", "4.mp4", "5.png")
+
+	mh := NewMediaHandler(NewURLBuilder(8080), nil, nil, t.TempDir())
+	mh.SetSelectedDir(root)
+	mh.LoadMediaFiles(5)
+	assert.Eventually(t, func() bool {
+		return mh.GetMediaPage(0, 10).Total == 5
+	}, time.Second, 10*time.Millisecond)
+
+	page := mh.GetMediaPage(3, 10)
+	assert.Len(t, page.Items, 2)
+	assert.Equal(t, 5, page.Total)
+
+	page = mh.GetMediaPage(10, 10)
+	assert.Empty(t, page.Items)
+
+	page = mh.GetMediaPage(0, 2)
+	assert.Len(t, page.Items, 2)
+	assert.Equal(t, 2, page.Limit)
+}
+
+func TestSortMedias(t *testing.T) {
+	now := time.Now()
+	medias := []MediaInfo{
+		{Name: "0010.jpg", Size: 30, Type: "image", ModTime: now.Add(-time.Hour)},
+		{Name: "0002.jpg", Size: 10, Type: "image", ModTime: now},
+		{Name: "0100.mp4", Size: 20, Type: "video", ModTime: now.Add(-2 * time.Hour)},
+		{Name: "a.jpg", Folder: "sub", Size: 40, Type: "image", ModTime: now.Add(time.Hour)},
+	}
+	names := func() []string {
+		var result []string
+		for _, media := range medias {
+			result = append(result, media.Name)
+		}
+		return result
+	}
+
+	sortMediaList(medias, SortOptions{Field: SortByName})
+	assert.Equal(t, []string{"0002.jpg", "0010.jpg", "0100.mp4", "a.jpg"}, names())
+
+	sortMediaList(medias, SortOptions{Field: SortBySize, Desc: true})
+	assert.Equal(t, []string{"0010.jpg", "0100.mp4", "0002.jpg", "a.jpg"}, names())
+
+	sortMediaList(medias, defaultSortOptions)
+	assert.Equal(t, []string{"0002.jpg", "0010.jpg", "0100.mp4", "a.jpg"}, names())
+
+	sortMediaList(medias, SortOptions{Field: SortByType, Desc: true})
+	assert.Equal(t, []string{"0100.mp4", "0002.jpg", "0010.jpg", "a.jpg"}, names())
+
+	medias[0].Video = &video.Meta{Duration: 12.5}
+	sortMediaList(medias, SortOptions{Field: SortByDuration, Desc: true})
+	assert.Equal(t, []string{"0100.mp4", "0002.jpg", "0010.jpg", "a.jpg"}, names())
+}
+
+func TestSetSortOptions(t *testing.T) {
+	mh := NewMediaHandler(NewURLBuilder(8080), nil, nil, t.TempDir())
+	mh.medias = []MediaInfo{
+		{Name: "b.jpg", Size: 20, Type: "image"},
+		{Name: "a.jpg", Size: 30, Type: "image"},
+		{Name: "c.jpg", Size: 10, Type: "image"},
+	}
+	names := func() []string {
+		var result []string
+		for _, media := range mh.GetMediaPage(0, 10).Items {
+			result = append(result, media.Name)
+		}
+		return result
+	}
+
+	// 连续修改排序选项时以最后一次为准，先完成的旧排序不会覆盖结果
+	for i := 0; i < 20; i++ {
+		assert.Nil(t, mh.SetSortOptions(SortOptions{Field: SortByName, Desc: i%2 == 0}))
+	}
+	assert.Nil(t, mh.SetSortOptions(SortOptions{Field: SortBySize}))
+	assert.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual([]string{"c.jpg", "b.jpg", "a.jpg"}, names())
+	}, time.Second, 10*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, []string{"c.jpg", "b.jpg", "a.jpg"}, names())
+
+	assert.NotNil(t, mh.SetSortOptions(SortOptions{Field: "color"}))
+}
@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+
+	"media-app/pkg/file"
+	"media-app/pkg/logger"
+	"media-app/pkg/watcher"
+
+	"go.uber.org/zap"
+)
+
+// watch 监听 dir 的变化，变化时增量同步扫描结果并推送差异
+func (mh *MediaHandler) watch(dir string, options ScanOptions, scanID int) {
+	watchOptions := watcher.DefaultOptions()
+	watchOptions.Recursive = options.Recursive
+	watchOptions.SkipDir = func(path string) bool {
+		relDir, err := filepath.Rel(dir, path)
+		if err != nil {
+			return true
+		}
+		depth := len(strings.Split(relDir, string(filepath.Separator)))
+		return options.skipDir(filepath.Base(path), depth)
+	}
+
+	w, err := watcher.New(dir, watchOptions, func() {
+		mh.syncChanges(dir, options, scanID)
+	})
+	if err != nil {
+		logger.Error("监听目录失败", zap.String("dir", dir), zap.Error(err))
+		return
+	}
+
+	mh.mux.Lock()
+	defer mh.mux.Unlock()
+	if mh.scanID != scanID {
+		w.Close()
+		return
+	}
+	if mh.watcher != nil {
+		mh.watcher.Close()
+	}
+	mh.watcher = w
+	logger.Info("开始监听目录", zap.String("dir", dir), zap.Bool("polling", w.Polling()))
+}
+
+// stopWatch 停止监听，调用方需持有 mh.mux
+func (mh *MediaHandler) stopWatch() {
+	if mh.watcher != nil {
+		mh.watcher.Close()
+		mh.watcher = nil
+	}
+}
+
+// Close stops the running scan and the directory watcher
+func (mh *MediaHandler) Close() {
+	mh.mux.Lock()
+	defer mh.mux.Unlock()
+	if mh.scanCancel != nil {
+		mh.scanCancel()
+	}
+	mh.stopWatch()
+}
+
+// syncChanges 重新扫描目录，与上次结果对比后推送 media-added/media-removed/media-changed
+func (mh *MediaHandler) syncChanges(dir string, options ScanOptions, scanID int) {
+	mh.syncMux.Lock()
+	defer mh.syncMux.Unlock()
+
+	var current []MediaInfo
+	err := mh.walkMedia(context.Background(), dir, options, func(media MediaInfo) {
+		current = append(current, media)
+	})
+	if err != nil {
+		logger.Error("同步目录变化失败", zap.String("dir", dir), zap.Error(err))
+		return
+	}
+
+	mh.mux.Lock()
+	if mh.scanID != scanID {
+		mh.mux.Unlock()
+		return
+	}
+	added, removed, changed := diffMedias(mh.medias, current)
+	if len(added) == 0 && len(removed) == 0 && len(changed) == 0 {
+		mh.mux.Unlock()
+		return
+	}
+	sortOptions := mh.sort
+	ix := mh.index
+	mh.mux.Unlock()
+
+	mh.remember(ix, dir, options, current)
+	mh.sortMedias(current, sortOptions)
+
+	mh.mux.Lock()
+	if mh.scanID != scanID {
+		mh.mux.Unlock()
+		return
+	}
+	mh.medias = current
+	mh.mux.Unlock()
+
+	logger.Info("目录内容已变化",
+		zap.String("dir", dir), zap.Int("added", len(added)), zap.Int("removed", len(removed)), zap.Int("changed", len(changed)))
+
+	if len(removed) > 0 {
+		mh.emit("media-removed", removed)
+	}
+	if len(changed) > 0 {
+		mh.emit("media-changed", changed)
+	}
+	if len(added) > 0 {
+		mh.emit("media-added", added)
+	}
+	if len(added) > 0 || len(removed) > 0 {
+		if fileCount, err := file.CountFiles(dir); err == nil {
+			mh.emit("media-count", fileCount)
+		}
+	}
+}
+
+// diffMedias 对比前后两次扫描结果，未变化的媒体沿用已读取的尺寸与拍摄时间
+func diffMedias(previous, current []MediaInfo) (added, removed, changed []MediaInfo) {
+	known := make(map[string]*MediaInfo, len(previous))
+	for i := range previous {
+		known[previous[i].Path] = &previous[i]
+	}
+
+	seen := make(map[string]bool, len(current))
+	for i := range current {
+		media := &current[i]
+		seen[media.Path] = true
+		old, ok := known[media.Path]
+		switch {
+		case !ok:
+			added = append(added, *media)
+		case old.Size != media.Size || !old.ModTime.Equal(media.ModTime):
+			changed = append(changed, *media)
+		default:
+			media.Width, media.Height, media.CaptureTime = old.Width, old.Height, old.CaptureTime
+			media.Exif, media.Video = old.Exif, old.Video
+		}
+	}
+	for _, media := range previous {
+		if !seen[media.Path] {
+			removed = append(removed, media)
+		}
+	}
+	return added, removed, changed
+}
@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffMedias(t *testing.T) {
+	now := time.Now()
+	previous := []MediaInfo{
+		{Path: "/a.jpg", Size: 1, ModTime: now, Width: 10, Height: 20},
+		{Path: "/b.jpg", Size: 1, ModTime: now},
+		{Path: "/c.jpg", Size: 1, ModTime: now},
+	}
+	current := []MediaInfo{
+		{Path: "/a.jpg", Size: 1, ModTime: now},
+		{Path: "/c.jpg", Size: 2, ModTime: now.Add(time.Second)},
+		{Path: "/d.jpg", Size: 1, ModTime: now},
+	}
+
+	added, removed, changed := diffMedias(previous, current)
+	assert.Equal(t, "/d.jpg", added[0].Path)
+	assert.Equal(t, "/b.jpg", removed[0].Path)
+	assert.Equal(t, "/c.jpg", changed[0].Path)
+	assert.Len(t, added, 1)
+	assert.Len(t, removed, 1)
+	assert.Len(t, changed, 1)
+	assert.Equal(t, 10, current[0].Width)
+}
@@ -38,32 +38,24 @@ type MoveRecord struct {
 // ShortcutHandler 快捷键处理器
 type ShortcutHandler struct {
 	ctx         context.Context
-	port        int
+	urls        *URLBuilder
 	dir         string // 当前工作目录
 	mux         sync.Mutex
 	configPath  string       // 配置文件路径
 	undoStack   []MoveRecord // 撤销栈
 	maxUndoSize int          // 最大撤销记录数
+	rootsMux    sync.Mutex
+	targetRoots map[string]bool // 已注册到文件服务的目标文件夹
 }
 
 // NewShortcutHandler 创建快捷键处理器
-func NewShortcutHandler(port int) *ShortcutHandler {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		logger.Error("获取用户目录失败", zap.Error(err))
-		homeDir = "."
-	}
-
-	configDir := filepath.Join(homeDir, ".media-app")
-	if err := os.MkdirAll(configDir, 0755); err != nil {
-		logger.Error("创建配置目录失败", zap.Error(err))
-	}
-
+func NewShortcutHandler(urls *URLBuilder) *ShortcutHandler {
 	return &ShortcutHandler{
-		port:        port,
-		configPath:  filepath.Join(configDir, "shortcuts.json"),
+		urls:        urls,
+		configPath:  filepath.Join(ConfigDir(), "shortcuts.json"),
 		undoStack:   make([]MoveRecord, 0),
 		maxUndoSize: 50,
+		targetRoots: make(map[string]bool),
 	}
 }
 
@@ -81,6 +73,8 @@ func (sh *ShortcutHandler) GetSelectedDir() string {
 func (sh *ShortcutHandler) SetSelectedDir(dir string) {
 	sh.mux.Lock()
 	defer sh.mux.Unlock()
+	dir = absDir(dir)
+	sh.urls.ReplaceRoot(sh.dir, dir)
 	sh.dir = dir
 	logger.Info("分类目录已选择", zap.String("dir", dir))
 }
@@ -103,9 +97,41 @@ func (sh *ShortcutHandler) GetShortcuts() []ShortcutConfig {
 		return sh.getDefaultShortcuts()
 	}
 
+	sh.syncTargetRoots(config.Shortcuts)
 	return config.Shortcuts
 }
 
+// syncTargetRoots 将绝对路径的目标文件夹注册到文件服务，以便预览移动到所选目录之外的文件；
+// 相对路径的目标文件夹位于源文件所在目录下，无需注册
+func (sh *ShortcutHandler) syncTargetRoots(shortcuts []ShortcutConfig) {
+	sh.rootsMux.Lock()
+	defer sh.rootsMux.Unlock()
+
+	wanted := make(map[string]bool)
+	for _, sc := range shortcuts {
+		if sc.TargetDir == "" || !filepath.IsAbs(sc.TargetDir) {
+			continue
+		}
+		dir := filepath.Clean(sc.TargetDir)
+		wanted[dir] = true
+		if sh.targetRoots[dir] {
+			continue
+		}
+		// 目标文件夹在第一次移动时才创建，注册失败时下次读取配置再重试
+		if _, err := sh.urls.RegisterRoot(dir); err != nil {
+			logger.Debug("目标文件夹暂不可用", zap.String("dir", dir), zap.Error(err))
+			continue
+		}
+		sh.targetRoots[dir] = true
+	}
+	for dir := range sh.targetRoots {
+		if !wanted[dir] {
+			sh.urls.UnregisterRoot(dir)
+			delete(sh.targetRoots, dir)
+		}
+	}
+}
+
 // getDefaultShortcuts 获取默认快捷键配置
 func (sh *ShortcutHandler) getDefaultShortcuts() []ShortcutConfig {
 	return []ShortcutConfig{
@@ -134,6 +160,7 @@ func (sh *ShortcutHandler) SaveShortcuts(shortcuts []ShortcutConfig) error {
 		return fmt.Errorf("写入配置失败: %w", err)
 	}
 
+	sh.syncTargetRoots(shortcuts)
 	logger.Info("快捷键配置已保存", zap.Int("count", len(shortcuts)))
 	return nil
 }
@@ -157,26 +184,39 @@ func (sh *ShortcutHandler) MoveByShortcut(filePath string, shortcutKey string) e
 
 	// 查找快捷键配置
 	shortcuts := sh.GetShortcuts()
-	var targetConfig *ShortcutConfig
-	for _, sc := range shortcuts {
-		if strings.EqualFold(sc.Key, shortcutKey) {
-			targetConfig = &sc
-			break
-		}
+	targetConfig, err := findShortcut(shortcuts, shortcutKey)
+	if err != nil {
+		return err
 	}
-
-	if targetConfig == nil {
-		return fmt.Errorf("未找到快捷键 %s 的配置", shortcutKey)
+	if _, err := sh.moveFile(filePath, targetConfig); err != nil {
+		return err
 	}
 
-	if targetConfig.TargetDir == "" {
-		return fmt.Errorf("快捷键 %s 未配置目标文件夹", shortcutKey)
+	// 目标文件夹可能刚刚创建
+	sh.syncTargetRoots(shortcuts)
+	return nil
+}
+
+// findShortcut 查找已配置目标文件夹的快捷键
+func findShortcut(shortcuts []ShortcutConfig, shortcutKey string) (ShortcutConfig, error) {
+	for _, sc := range shortcuts {
+		if !strings.EqualFold(sc.Key, shortcutKey) {
+			continue
+		}
+		if sc.TargetDir == "" {
+			return sc, fmt.Errorf("快捷键 %s 未配置目标文件夹", shortcutKey)
+		}
+		return sc, nil
 	}
+	return ShortcutConfig{}, fmt.Errorf("未找到快捷键 %s 的配置", shortcutKey)
+}
 
+// moveFile 将文件移动到快捷键的目标文件夹并记录到撤销栈，调用方需持有 mux
+func (sh *ShortcutHandler) moveFile(filePath string, targetConfig ShortcutConfig) (MoveRecord, error) {
 	// 检查源文件是否存在
 	if _, err := os.Stat(filePath); err != nil {
 		logger.Error("源文件不存在", zap.String("path", filePath), zap.Error(err))
-		return fmt.Errorf("文件不存在: %s", filePath)
+		return MoveRecord{}, fmt.Errorf("文件不存在: %s", filePath)
 	}
 
 	// 解析目标目录
@@ -185,7 +225,7 @@ func (sh *ShortcutHandler) MoveByShortcut(filePath string, shortcutKey string) e
 	// 创建目标目录
 	if err := os.MkdirAll(targetDir, 0755); err != nil {
 		logger.Error("创建目标目录失败", zap.String("dir", targetDir), zap.Error(err))
-		return fmt.Errorf("创建目标目录失败: %w", err)
+		return MoveRecord{}, fmt.Errorf("创建目标目录失败: %w", err)
 	}
 
 	// 构建目标文件路径
@@ -198,23 +238,40 @@ func (sh *ShortcutHandler) MoveByShortcut(filePath string, shortcutKey string) e
 	// 移动文件
 	if err := file.RenameFile(filePath, targetPath, true, 100); err != nil {
 		logger.Error("移动文件失败", zap.String("source", filePath), zap.String("target", targetPath), zap.Error(err))
-		return fmt.Errorf("移动文件失败: %w", err)
+		return MoveRecord{}, fmt.Errorf("移动文件失败: %w", err)
 	}
 
 	// 记录到撤销栈
-	sh.pushUndoRecord(MoveRecord{
+	record := MoveRecord{
 		SourcePath: filePath,
 		TargetPath: targetPath,
 		Timestamp:  time.Now(),
-	})
+	}
+	sh.pushUndoRecord(record)
 
 	logger.Info("文件已移动",
 		zap.String("source", filePath),
 		zap.String("target", targetPath),
-		zap.String("shortcut", shortcutKey),
+		zap.String("shortcut", targetConfig.Key),
 		zap.String("label", targetConfig.Label))
 
-	return nil
+	return record, nil
+}
+
+// CategoryDir 返回快捷键对应的分类目标文件夹，相对路径以分类目录为基准
+func (sh *ShortcutHandler) CategoryDir(shortcutKey string) (ShortcutConfig, string, error) {
+	sc, err := findShortcut(sh.GetShortcuts(), shortcutKey)
+	if err != nil {
+		return sc, "", err
+	}
+	if filepath.IsAbs(sc.TargetDir) {
+		return sc, sc.TargetDir, nil
+	}
+	dir := sh.GetSelectedDir()
+	if dir == "" {
+		return sc, "", fmt.Errorf("未选择分类目录")
+	}
+	return sc, filepath.Join(dir, sc.TargetDir), nil
 }
 
 // resolveTargetDir 解析目标目录（支持相对路径）
@@ -3,8 +3,13 @@ package handler
 import (
 	"context"
 	"fmt"
-	"image/jpeg"
+	"image"
+	"io/fs"
+	"media-app/pkg/event"
+	"media-app/pkg/exif"
 	"media-app/pkg/file"
+	"media-app/pkg/imaging"
+	"media-app/pkg/index"
 	"media-app/pkg/logger"
 	"os"
 	"path/filepath"
@@ -13,16 +18,20 @@ import (
 	"time"
 
 	"github.com/corona10/goimagehash"
-	"github.com/wailsapp/wails/v2/pkg/runtime"
 	"go.uber.org/zap"
 )
 
 // SimilarHandler handles similarity analysis
 type SimilarHandler struct {
-	dir  string
-	mux  sync.Mutex
-	ctx  context.Context
-	port int
+	dir    string
+	mux    sync.Mutex
+	ctx    context.Context
+	urls   *URLBuilder
+	events *event.Bus
+	store  *index.Store // 媒体索引存储，用于复用已计算的哈希
+
+	jobsMux sync.Mutex
+	jobs    map[string]*SimilarJob // 相似度分析任务
 }
 
 // HashResult 哈希结果
@@ -47,9 +56,12 @@ type SimilarityResult struct {
 }
 
 // NewSimilarHandler creates a new SimilarHandler instance
-func NewSimilarHandler(port int) *SimilarHandler {
+func NewSimilarHandler(urls *URLBuilder, store *index.Store, events *event.Bus) *SimilarHandler {
 	return &SimilarHandler{
-		port: port,
+		urls:   urls,
+		events: events,
+		store:  store,
+		jobs:   make(map[string]*SimilarJob),
 	}
 }
 
@@ -58,6 +70,11 @@ func (sh *SimilarHandler) SetContext(ctx context.Context) {
 	sh.ctx = ctx
 }
 
+// emit 通过事件总线发送事件，未设置事件总线时忽略
+func (sh *SimilarHandler) emit(name string, data any) {
+	sh.events.Emit(name, data)
+}
+
 // GetSelectedDir returns the selected directory
 func (sh *SimilarHandler) GetSelectedDir() string {
 	return sh.dir
@@ -67,6 +84,8 @@ func (sh *SimilarHandler) GetSelectedDir() string {
 func (sh *SimilarHandler) SetSelectedDir(dir string) {
 	sh.mux.Lock()
 	defer sh.mux.Unlock()
+	dir = absDir(dir)
+	sh.urls.ReplaceRoot(sh.dir, dir)
 	sh.dir = dir
 	logger.Info("目录已选择", zap.String("dir", dir))
 }
@@ -80,31 +99,45 @@ func (sh *SimilarHandler) CalcSimilarity() []SimilarityResult {
 	}
 
 	// 初始化映射
+	ix := sh.store.Open(dir)
 	fileMap := make(map[string]*os.File)
+	infoMap := make(map[string]fs.FileInfo)
 	hashMap := make(map[string]*goimagehash.ImageHash)
 	var imgPaths []string
 
-	// 遍历目录，收集所有.jpg文件
+	// 遍历目录，收集所有图片文件，跳过 .delete 等整理目录与隐藏目录，避免重复项被再次处理
 	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
 		if err != nil {
 			return fmt.Errorf("walk dir error: %w", err)
 		}
 		if d.IsDir() {
+			if path != dir && file.FilterDir(d.Name()) {
+				return filepath.SkipDir
+			}
 			return nil
 		}
-		// 只处理.jpg后缀
-		if ext := filepath.Ext(path); ext != ".jpg" {
+		// 只处理图片，后缀不区分大小写
+		if file.GetFileTypeByExt(path) != file.MediaTypeImage {
 			return nil
 		}
 		if strings.HasPrefix(filepath.Base(path), ".") {
 			return nil
 		}
+		imgPaths = append(imgPaths, path)
+		// 索引中已有哈希时无需重新解码
+		info, err := d.Info()
+		if err == nil {
+			infoMap[path] = info
+			if e, ok := ix.Get(path, info.Size(), info.ModTime()); ok && e.HashSet {
+				hashMap[path] = goimagehash.NewImageHash(e.Hash, goimagehash.AHash)
+				return nil
+			}
+		}
 		f, err := os.Open(path)
 		if err != nil {
 			return fmt.Errorf("open file %s error: %w", path, err)
 		}
 		fileMap[path] = f
-		imgPaths = append(imgPaths, path)
 		return nil
 	})
 	if err != nil {
@@ -118,13 +151,24 @@ func (sh *SimilarHandler) CalcSimilarity() []SimilarityResult {
 		}
 	}()
 
-	hashResults := calcAverageHash(imgPaths, fileMap)
+	var hashPaths []string
+	for path := range fileMap {
+		hashPaths = append(hashPaths, path)
+	}
+	hashResults := calcAverageHash(hashPaths, fileMap)
 	for _, hashResult := range hashResults {
 		if hashResult.hash == nil {
 			continue
 		}
 		hashMap[hashResult.path] = hashResult.hash
+		if info, ok := infoMap[hashResult.path]; ok {
+			sh.rememberHash(ix, dir, hashResult.path, info, hashResult.hash)
+		}
+	}
+	if err := ix.Save(); err != nil {
+		logger.Error("保存媒体索引失败", zap.String("dir", dir), zap.Error(err))
 	}
+	logger.Infof("复用索引中的哈希 %d 个，新计算 %d 个", len(imgPaths)-len(hashPaths), len(hashPaths))
 
 	// 过滤出有效的图片路径（有哈希值的）
 	var validPaths []string
@@ -193,13 +237,10 @@ func (sh *SimilarHandler) CalcSimilarity() []SimilarityResult {
 				logger.Error("获取相对路径失败", zap.String("path", path), zap.Error(err))
 				continue
 			}
-			urlPath := strings.ReplaceAll(relPath, string(filepath.Separator), "/")
-			// 添加修改时间戳防止浏览器缓存
-			modTimeUnix := meta.ModTime.Unix()
 			images = append(images, SimilarImage{
 				Path:    meta.FullPath,
 				Name:    meta.FileName,
-				Url:     fmt.Sprintf("http://localhost:%d/%s?t=%d", sh.port, urlPath, modTimeUnix),
+				Url:     sh.urls.FileURL(dir, relPath, meta.ModTime),
 				Size:    getFileSize(meta.FullPath),
 				ModTime: meta.ModTime,
 			})
@@ -216,6 +257,19 @@ func (sh *SimilarHandler) CalcSimilarity() []SimilarityResult {
 	return results
 }
 
+// rememberHash 将图片的平均哈希记录到索引
+func (sh *SimilarHandler) rememberHash(ix *index.Index, dir, path string, info fs.FileInfo, hash *goimagehash.ImageHash) {
+	folder := ""
+	if relDir, err := filepath.Rel(dir, filepath.Dir(path)); err == nil && relDir != "." {
+		folder = filepath.ToSlash(relDir)
+	}
+	ix.Update(path, info.Size(), info.ModTime(), func(e *index.Entry) {
+		e.Name, e.Folder, e.Type = info.Name(), folder, file.MediaTypeImage
+		e.HashSet = true
+		e.Hash = hash.GetHash()
+	})
+}
+
 // getFileSize 获取文件大小
 func getFileSize(path string) int64 {
 	info, err := os.Stat(path)
@@ -227,7 +281,7 @@ func getFileSize(path string) int64 {
 
 // SendSimilarResults 发送相似图片结果到前端
 func (sh *SimilarHandler) SendSimilarResults(results []SimilarityResult) {
-	runtime.EventsEmit(sh.ctx, "similar-results", results)
+	sh.emit("similar-results", results)
 	logger.Infof("已发送 %d 组相似图片到前端", len(results))
 }
 
@@ -336,12 +390,16 @@ func calcAverageHash(imgPath []string, fileMap map[string]*os.File) []HashResult
 					resChan <- HashResult{path, nil}
 					continue
 				}
-				img, err := jpeg.Decode(f)
+				img, _, err := image.Decode(f)
 				if err != nil {
 					logger.Errorf("解码图片 %s 失败: %v", path, err)
 					resChan <- HashResult{path, nil}
 					continue
 				}
+				// 按 EXIF 方向摆正后再计算，带方向标记的照片与摆正保存的副本哈希一致
+				if meta, err := exif.Decode(f); err == nil {
+					img = imaging.Orient(img, meta.Orientation)
+				}
 				hash, err := goimagehash.AverageHash(img)
 				if err != nil {
 					logger.Errorf("计算哈希 %s 失败: %v", path, err)
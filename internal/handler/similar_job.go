@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"media-app/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// maxSimilarJobs 保留的相似度分析任务数，超出时移除最早结束的任务
+const maxSimilarJobs = 16
+
+// JobState 后台任务状态
+type JobState string
+
+const (
+	JobRunning JobState = "running" // 执行中
+	JobDone    JobState = "done"    // 已完成
+)
+
+// SimilarJob 相似度分析任务
+type SimilarJob struct {
+	ID         string             `json:"id"`
+	Dir        string             `json:"dir"`
+	State      JobState           `json:"state"`
+	StartedAt  time.Time          `json:"startedAt"`
+	FinishedAt *time.Time         `json:"finishedAt,omitempty"`
+	Groups     int                `json:"groups"`            // 相似图片组数
+	Results    []SimilarityResult `json:"results,omitempty"` // 分析结果，列表中不包含
+}
+
+// StartJob 在后台分析所选目录的相似图片，结果通过 similar-results 事件发送并保存在任务中
+func (sh *SimilarHandler) StartJob() (SimilarJob, error) {
+	dir := sh.GetSelectedDir()
+	if dir == "" {
+		return SimilarJob{}, fmt.Errorf("未选择文件夹")
+	}
+	job := &SimilarJob{ID: newToken()[:12], Dir: dir, State: JobRunning, StartedAt: time.Now()}
+
+	sh.jobsMux.Lock()
+	sh.jobs[job.ID] = job
+	sh.pruneJobs()
+	snapshot := *job
+	sh.jobsMux.Unlock()
+
+	logger.Info("开始相似度分析", zap.String("id", job.ID), zap.String("dir", dir))
+	sh.emit("similar-loading", true)
+	go func() {
+		results := sh.CalcSimilarity()
+		finishedAt := time.Now()
+		sh.jobsMux.Lock()
+		job.State = JobDone
+		job.FinishedAt = &finishedAt
+		job.Groups = len(results)
+		job.Results = results
+		sh.jobsMux.Unlock()
+		sh.SendSimilarResults(results)
+	}()
+	return snapshot, nil
+}
+
+// Job 返回任务及其分析结果
+func (sh *SimilarHandler) Job(id string) (SimilarJob, bool) {
+	sh.jobsMux.Lock()
+	defer sh.jobsMux.Unlock()
+	job, ok := sh.jobs[id]
+	if !ok {
+		return SimilarJob{}, false
+	}
+	return *job, true
+}
+
+// Jobs 返回所有任务，最新的在前，不包含分析结果
+func (sh *SimilarHandler) Jobs() []SimilarJob {
+	sh.jobsMux.Lock()
+	defer sh.jobsMux.Unlock()
+	jobs := make([]SimilarJob, 0, len(sh.jobs))
+	for _, job := range sh.jobs {
+		summary := *job
+		summary.Results = nil
+		jobs = append(jobs, summary)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].StartedAt.After(jobs[j].StartedAt) })
+	return jobs
+}
+
+// pruneJobs 移除最早结束的任务，调用方需持有 jobsMux
+func (sh *SimilarHandler) pruneJobs() {
+	for len(sh.jobs) > maxSimilarJobs {
+		var oldest *SimilarJob
+		for _, job := range sh.jobs {
+			if job.State == JobDone && (oldest == nil || job.StartedAt.Before(oldest.StartedAt)) {
+				oldest = job
+			}
+		}
+		if oldest == nil {
+			return
+		}
+		delete(sh.jobs, oldest.ID)
+	}
+}
@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"image"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"media-app/pkg/index"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalcSimilarity(t *testing.T) {
+	root := t.TempDir()
+	img := image.NewGray(image.Rect(0, 0, 16, 16))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(i)
+	}
+	for _, name := range []string{"a.jpg", "B.JPEG", "c.png", ".delete/d.jpg", "sub/.star/e.jpg", "sub/f.jpg"} {
+		path := filepath.Join(root, name)
+		assert.Nil(t, os.MkdirAll(filepath.Dir(path), 0755))
+		f, err := os.Create(path)
+		assert.Nil(t, err)
+		if filepath.Ext(name) == ".png" {
+			assert.Nil(t, png.Encode(f, img))
+		} else {
+			assert.Nil(t, jpeg.Encode(f, img, nil))
+		}
+		assert.Nil(t, f.Close())
+	}
+
+	sh := NewSimilarHandler(NewURLBuilder(8080), index.NewStore(t.TempDir()), nil)
+	sh.SetSelectedDir(root)
+	results := sh.CalcSimilarity()
+
+	// 各种图片格式与大写后缀都参与比较，.delete 等整理目录中的图片不参与
+	assert.Len(t, results, 1)
+	var paths []string
+	for _, image := range results[0].Images {
+		paths = append(paths, image.Path)
+	}
+	assert.ElementsMatch(t, []string{
+		filepath.Join(root, "a.jpg"),
+		filepath.Join(root, "B.JPEG"),
+		filepath.Join(root, "c.png"),
+		filepath.Join(root, "sub", "f.jpg"),
+	}, paths)
+}
@@ -0,0 +1,249 @@
+package handler
+
+import (
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"media-app/pkg/logger"
+	"media-app/pkg/thumb"
+
+	"go.uber.org/zap"
+)
+
+// TokenParam 文件服务访问令牌的查询参数名
+const TokenParam = "token"
+
+// RootPrefix 根目录在 URL 中的前缀，文件 URL 为 /r/<根目录 ID>/<相对路径>
+const RootPrefix = "/r/"
+
+// Root 文件服务允许访问的根目录
+type Root struct {
+	ID  string `json:"id"`  // 根目录 ID，由路径计算
+	Dir string `json:"dir"` // 根目录绝对路径
+}
+
+// rootRef 已注册的根目录及其注册次数
+type rootRef struct {
+	dir  string
+	refs int
+}
+
+// URLBuilder 生成文件服务的访问 URL，URL 中带有每次启动随机生成的访问令牌。
+// 同时维护文件服务允许访问的根目录，URL 以根目录 ID 为前缀
+type URLBuilder struct {
+	mux   sync.RWMutex
+	port  int
+	token string
+	roots map[string]*rootRef // 根目录 ID -> 根目录
+}
+
+// NewURLBuilder 创建 URL 生成器并生成新的访问令牌
+func NewURLBuilder(port int) *URLBuilder {
+	return &URLBuilder{
+		port:  port,
+		token: newToken(),
+		roots: make(map[string]*rootRef),
+	}
+}
+
+// newToken 生成随机访问令牌
+func newToken() string {
+	buf := make([]byte, 16)
+	// crypto/rand.Read 在受支持的平台上不会失败
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// Token returns the access token of this launch
+func (b *URLBuilder) Token() string {
+	return b.token
+}
+
+// Port returns the port the file server listens on
+func (b *URLBuilder) Port() int {
+	b.mux.RLock()
+	defer b.mux.RUnlock()
+	return b.port
+}
+
+// SetPort 修改文件服务端口，服务实际监听的端口与预期不同时调用
+func (b *URLBuilder) SetPort(port int) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	b.port = port
+}
+
+// RootID 计算根目录的 ID，同一目录每次启动得到相同的 ID，浏览器缓存得以复用。
+// 按绝对路径计算，与注册时一致，相对路径生成的 URL 也能找到根目录
+func RootID(dir string) string {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	sum := sha1.Sum([]byte(filepath.Clean(dir)))
+	return hex.EncodeToString(sum[:6])
+}
+
+// RegisterRoot 注册文件服务允许访问的根目录。
+// 同一目录可被多处注册，注销相同次数后才不再允许访问
+func (b *URLBuilder) RegisterRoot(dir string) (Root, error) {
+	if dir == "" {
+		return Root{}, fmt.Errorf("根目录不能为空")
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return Root{}, fmt.Errorf("解析根目录失败: %w", err)
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return Root{}, fmt.Errorf("读取根目录失败: %w", err)
+	}
+	if !info.IsDir() {
+		return Root{}, fmt.Errorf("不是目录: %s", abs)
+	}
+
+	id := RootID(abs)
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	ref, ok := b.roots[id]
+	if !ok {
+		ref = &rootRef{dir: abs}
+		b.roots[id] = ref
+		logger.Info("注册文件服务根目录", zap.String("id", id), zap.String("dir", abs))
+	}
+	ref.refs++
+	return Root{ID: id, Dir: abs}, nil
+}
+
+// UnregisterRoot 注销一次根目录的注册
+func (b *URLBuilder) UnregisterRoot(dir string) {
+	if dir == "" {
+		return
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return
+	}
+	id := RootID(abs)
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	ref, ok := b.roots[id]
+	if !ok {
+		return
+	}
+	if ref.refs--; ref.refs <= 0 {
+		delete(b.roots, id)
+		logger.Info("注销文件服务根目录", zap.String("id", id), zap.String("dir", ref.dir))
+	}
+}
+
+// ReplaceRoot 处理器切换目录时注销 old 并注册 dir
+func (b *URLBuilder) ReplaceRoot(old, dir string) {
+	if old == dir {
+		return
+	}
+	if dir != "" {
+		if _, err := b.RegisterRoot(dir); err != nil {
+			logger.Error("注册文件服务根目录失败", zap.String("dir", dir), zap.Error(err))
+		}
+	}
+	b.UnregisterRoot(old)
+}
+
+// Root 根据 ID 查找已注册的根目录
+func (b *URLBuilder) Root(id string) (string, bool) {
+	b.mux.RLock()
+	defer b.mux.RUnlock()
+	ref, ok := b.roots[id]
+	if !ok {
+		return "", false
+	}
+	return ref.dir, true
+}
+
+// Roots 返回所有已注册的根目录，按路径排序
+func (b *URLBuilder) Roots() []Root {
+	b.mux.RLock()
+	defer b.mux.RUnlock()
+	roots := make([]Root, 0, len(b.roots))
+	for id, ref := range b.roots {
+		roots = append(roots, Root{ID: id, Dir: ref.dir})
+	}
+	sort.Slice(roots, func(i, j int) bool {
+		return roots[i].Dir < roots[j].Dir
+	})
+	return roots
+}
+
+// FileURL 生成 root 下 relPath 的访问 URL，带修改时间戳防止浏览器缓存旧内容。
+// root 需要已注册，否则文件服务返回 404
+func (b *URLBuilder) FileURL(root, relPath string, modTime time.Time) string {
+	return b.build(rootPath(root, relPath), modTime)
+}
+
+// ThumbURL 生成 root 下 relPath 的缩略图 URL
+func (b *URLBuilder) ThumbURL(root, relPath string, size int, modTime time.Time) string {
+	return b.build(fmt.Sprintf("%s%d%s", thumb.URLPrefix, size, rootPath(root, relPath)), modTime)
+}
+
+// ExportURL 生成导出任务的下载 URL
+func (b *URLBuilder) ExportURL(id string) string {
+	return b.build(ExportPrefix+id, time.Time{})
+}
+
+// rootPath 拼接带根目录前缀的 URL 路径
+func rootPath(root, relPath string) string {
+	return RootPrefix + RootID(root) + "/" + filepath.ToSlash(relPath)
+}
+
+// build 拼接地址、转义路径并附加时间戳与令牌，modTime 为零值时不附加时间戳
+func (b *URLBuilder) build(path string, modTime time.Time) string {
+	query := url.Values{}
+	if !modTime.IsZero() {
+		query.Set("t", fmt.Sprint(modTime.Unix()))
+	}
+	query.Set(TokenParam, b.token)
+	u := url.URL{
+		Scheme:   "http",
+		Host:     fmt.Sprintf("127.0.0.1:%d", b.Port()),
+		Path:     path,
+		RawQuery: query.Encode(),
+	}
+	return u.String()
+}
+
+// withinRoots path 解析符号链接后是否位于 roots 中的某个目录内，空目录忽略
+func withinRoots(roots []string, path string) bool {
+	realPath, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return false
+	}
+	if realPath, err = filepath.Abs(realPath); err != nil {
+		return false
+	}
+	for _, root := range roots {
+		if root == "" {
+			continue
+		}
+		realRoot, err := filepath.EvalSymlinks(root)
+		if err != nil {
+			continue
+		}
+		if realRoot, err = filepath.Abs(realRoot); err != nil {
+			continue
+		}
+		rel, err := filepath.Rel(realRoot, realPath)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestURLBuilder(t *testing.T) {
+	urls := NewURLBuilder(8080)
+	assert.Len(t, urls.Token(), 32)
+	assert.NotEqual(t, urls.Token(), NewURLBuilder(8080).Token())
+
+	modTime := time.Unix(1700000000, 0)
+	root := t.TempDir()
+	prefix := RootPrefix + RootID(root)
+	raw := urls.FileURL(root, filepath.Join("2024 旅行", "a#1.jpg"), modTime)
+	u, err := url.Parse(raw)
+	assert.Nil(t, err)
+	assert.Equal(t, "127.0.0.1:8080", u.Host)
+	assert.Equal(t, prefix+"/2024 旅行/a#1.jpg", u.Path)
+	assert.Equal(t, "1700000000", u.Query().Get("t"))
+	assert.Equal(t, urls.Token(), u.Query().Get(TokenParam))
+
+	u, err = url.Parse(urls.ThumbURL(root, "a.jpg", 320, modTime))
+	assert.Nil(t, err)
+	assert.Equal(t, "/_thumb/320"+prefix+"/a.jpg", u.Path)
+
+	// 切换目录时注销旧目录
+	other := t.TempDir()
+	urls.ReplaceRoot("", root)
+	urls.ReplaceRoot(root, other)
+	_, ok := urls.Root(RootID(root))
+	assert.False(t, ok)
+	dir, ok := urls.Root(RootID(other))
+	assert.True(t, ok)
+	assert.Equal(t, other, dir)
+	assert.Equal(t, []Root{{ID: RootID(other), Dir: other}}, urls.Roots())
+
+	// 相对路径与绝对路径得到相同的 ID
+	t.Chdir(filepath.Dir(other))
+	rel := filepath.Base(other)
+	assert.Equal(t, RootID(other), RootID(rel))
+	u, err = url.Parse(urls.FileURL(rel, "a.jpg", modTime))
+	assert.Nil(t, err)
+	assert.Equal(t, RootPrefix+RootID(other)+"/a.jpg", u.Path)
+}
@@ -1,7 +1,6 @@
 package menu
 
 import (
-	"media-app/pkg/file"
 	"runtime"
 
 	"media-app/internal/app"
@@ -23,10 +22,11 @@ func Create(app *app.App) *menu.Menu {
 	fileMenu := appMenu.AddSubmenu("文件")
 	fileMenu.AddText("选择文件夹", keys.CmdOrCtrl("o"), func(_ *menu.CallbackData) { openDirectory(app) })
 	fileMenu.AddSeparator()
+	fileMenu.AddCheckbox("包含子文件夹", false, nil, func(data *menu.CallbackData) { toggleRecursive(app, data.MenuItem.Checked) })
 
 	operMenu := appMenu.AddSubmenu("操作")
-	operMenu.AddText("修复文件名", &keys.Accelerator{}, func(_ *menu.CallbackData) { app.MediaHandler.FixMediaFilename() })
-	operMenu.AddText("修复文件名（批量）", &keys.Accelerator{}, func(_ *menu.CallbackData) { app.MediaHandler.BatchFixMediaFilename() })
+	operMenu.AddText("修复文件名", &keys.Accelerator{}, func(_ *menu.CallbackData) { app.Events.Emit("rename-preview", nil) })
+	operMenu.AddText("修复文件名（批量）", &keys.Accelerator{}, func(_ *menu.CallbackData) { app.Events.Emit("batch-rename-setup", nil) })
 	operMenu.AddSeparator()
 	operMenu.AddText("查找相同图片", keys.CmdOrCtrl("f"), func(_ *menu.CallbackData) { findSimilarImages(app) })
 	operMenu.AddText("快捷分类", keys.CmdOrCtrl("k"), func(_ *menu.CallbackData) { openClassify(app) })
@@ -44,38 +44,34 @@ func openDirectory(app *app.App) string {
 		logger.Debug("用户取消选择目录")
 		return ""
 	}
-	app.MediaHandler.SetSelectedDir(filepath)
-	app.SimilarHandler.SetSelectedDir(filepath)
-	app.ShortcutHandler.SetSelectedDir(filepath)
-	fileCount, err := file.CountFiles(filepath)
-	if err != nil {
-		logger.Error("读取文件失败", zap.Error(err))
+	if err := app.OpenDir(filepath); err != nil {
+		logger.Error("打开目录失败", zap.Error(err))
 		return ""
 	}
-	if fileCount != 0 {
-		app.MediaHandler.SendMediaFiles(app.MediaHandler.GetMediaFiles(), fileCount, filepath)
-	}
 	// 跳转回首页
 	Goto(app, "/")
 	return filepath
 }
 
+// toggleRecursive 切换是否递归扫描子文件夹
+func toggleRecursive(app *app.App, recursive bool) {
+	options := app.MediaHandler.GetScanOptions()
+	options.Recursive = recursive
+	app.SetScanOptions(options)
+}
+
 // findSimilarImages 查找相似图片
 func findSimilarImages(app *app.App) {
-	// 发送加载状态
-	wailsruntime.EventsEmit(app.Context(), "similar-loading", true)
+	// 异步执行相似度分析，加载状态与结果通过事件发送
+	if _, err := app.SimilarHandler.StartJob(); err != nil {
+		logger.Warn("无法开始相似度分析", zap.Error(err))
+	}
 	// 跳转到相似图片页面
 	Goto(app, "/similar")
-
-	// 异步执行相似度分析
-	go func() {
-		results := app.SimilarHandler.CalcSimilarity()
-		app.SimilarHandler.SendSimilarResults(results)
-	}()
 }
 
 func Goto(app *app.App, route string) {
-	wailsruntime.EventsEmit(app.Context(), "router", route)
+	app.Events.Emit("router", route)
 }
 
 // openClassify 打开快捷分类页面
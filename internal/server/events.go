@@ -0,0 +1,76 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"media-app/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// EventsPath 事件流（Server-Sent Events）的路径，可通过 names=a,b 只订阅指定事件
+const EventsPath = "/_events"
+
+const (
+	eventBuffer       = 256              // 每个订阅者缓冲的事件数，处理过慢时断开
+	heartbeatInterval = 15 * time.Second // 心跳间隔，防止空闲连接被代理关闭
+)
+
+// serveEvents 以 Server-Sent Events 推送与 Wails 前端相同的事件，
+// 事件名为 Wails 事件名，数据为 JSON；连接断开后不补发错过的事件
+func (hs *HttpServer) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if hs.events == nil || !ok {
+		http.Error(w, "不支持事件流", http.StatusNotFound)
+		return
+	}
+	var names []string
+	for _, name := range strings.Split(r.URL.Query().Get("names"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	events, cancel := hs.events.Subscribe(eventBuffer, names...)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	// 客户端断开后按 retry 指定的毫秒数重连
+	_, _ = fmt.Fprint(w, "retry: 2000\n\n")
+	flusher.Flush()
+	logger.Debug("事件流已连接", zap.String("remote", r.RemoteAddr), zap.Strings("names", names))
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			logger.Debug("事件流已断开", zap.String("remote", r.RemoteAddr))
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		case e, ok := <-events:
+			if !ok {
+				logger.Warn("事件流处理过慢，已断开", zap.String("remote", r.RemoteAddr))
+				return
+			}
+			data, err := json.Marshal(e.Data)
+			if err != nil {
+				logger.Error("序列化事件失败", zap.String("name", e.Name), zap.Error(err))
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Name, data); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
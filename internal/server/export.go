@@ -0,0 +1,57 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"media-app/internal/handler"
+	"media-app/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// serveExport 以下载的形式流式返回导出任务的 ZIP，路径为 /_export/<导出 ID>。
+// ZIP 边生成边发送，没有 Content-Length；客户端断开时停止生成
+func (hs *HttpServer) serveExport(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, handler.ExportPrefix)
+	if hs.exports == nil {
+		http.NotFound(w, r)
+		return
+	}
+	info, ok := hs.exports.Info(id)
+	if !ok {
+		http.Error(w, "导出任务不存在或已开始下载", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename*=UTF-8''%s", url.PathEscape(info.Name)))
+	w.Header().Set("Cache-Control", "no-store")
+	if r.Method == http.MethodHead {
+		return
+	}
+	tw := &trackingWriter{ResponseWriter: w}
+	if err := hs.exports.Stream(r.Context(), id, tw); err != nil {
+		logger.Warn("导出下载中断", zap.String("id", id), zap.Error(err))
+		if !tw.written {
+			w.Header().Del("Content-Disposition")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		// 响应已经开始发送，只能中断连接让客户端知道下载不完整
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// trackingWriter 记录是否已经开始写入响应
+type trackingWriter struct {
+	http.ResponseWriter
+	written bool
+}
+
+func (w *trackingWriter) Write(p []byte) (int, error) {
+	w.written = true
+	return w.ResponseWriter.Write(p)
+}
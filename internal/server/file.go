@@ -0,0 +1,99 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// errOutsideRoot 路径不在允许访问的目录内
+var errOutsideRoot = errors.New("路径不在允许访问的目录内")
+
+// immutableMaxAge URL 带有匹配的修改时间戳时的缓存时长（秒）
+const immutableMaxAge = 365 * 24 * 3600
+
+// resolvePath 将 URL 路径解析为 root 下的文件路径，
+// 解析符号链接后仍必须位于 root 内，防止通过 .. 或符号链接访问其他文件
+func resolvePath(root, uri string) (string, error) {
+	if uri == "" || strings.ContainsRune(uri, 0) {
+		return "", fmt.Errorf("无效的路径：%q", uri)
+	}
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", fmt.Errorf("解析根目录失败：%w", err)
+	}
+
+	target := filepath.Join(root, filepath.FromSlash(path.Clean("/"+uri)))
+	realTarget, err := filepath.EvalSymlinks(target)
+	if err != nil {
+		// 文件不存在时交给后续处理返回 404
+		if os.IsNotExist(err) {
+			return target, nil
+		}
+		return "", fmt.Errorf("解析路径失败：%w", err)
+	}
+	if !within(realRoot, realTarget) {
+		return "", errOutsideRoot
+	}
+	return realTarget, nil
+}
+
+// within path 是否位于 root 内
+func within(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+// serveFile 返回文件内容，支持 ETag/Last-Modified 条件请求与 Range 请求
+func serveFile(w http.ResponseWriter, r *http.Request, path string) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			http.Error(w, "文件不存在", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "无法读取文件", http.StatusForbidden)
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		http.Error(w, "无法读取文件", http.StatusInternalServerError)
+		return
+	}
+	if info.IsDir() {
+		http.Error(w, "指定路径不是文件", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("ETag", etag(info))
+	// 调用方已指定缓存策略时不覆盖
+	if w.Header().Get("Cache-Control") == "" {
+		w.Header().Set("Cache-Control", cacheControl(r, info))
+	}
+	// ServeContent 负责 If-None-Match、If-Modified-Since、If-Range 与 Range 的处理
+	http.ServeContent(w, r, info.Name(), info.ModTime(), f)
+}
+
+// etag 由文件大小与修改时间生成强校验值
+func etag(info os.FileInfo) string {
+	return fmt.Sprintf(`"%x-%x"`, info.Size(), info.ModTime().UnixNano())
+}
+
+// cacheControl URL 中的 t 参数与文件修改时间一致时内容不会再变化，可长期缓存；
+// 否则每次使用前都需要校验
+func cacheControl(r *http.Request, info os.FileInfo) string {
+	if t, err := strconv.ParseInt(r.URL.Query().Get("t"), 10, 64); err == nil && t == info.ModTime().Unix() {
+		return fmt.Sprintf("private, max-age=%d, immutable", immutableMaxAge)
+	}
+	return "no-cache"
+}
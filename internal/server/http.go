@@ -1,47 +1,115 @@
 package server
 
 import (
+	"crypto/subtle"
 	"errors"
 	"fmt"
 	"net"
 	"net/http"
-	"path/filepath"
+	"net/url"
+	"os"
+	"strconv"
 	"strings"
 	"sync"
 
 	"media-app/internal/handler"
+	"media-app/pkg/event"
+	"media-app/pkg/imaging"
 	"media-app/pkg/logger"
+	"media-app/pkg/thumb"
 
 	"go.uber.org/zap"
 )
 
+// defaultHost 默认只监听本机回环地址，局域网内的其他主机无法访问
+const defaultHost = "127.0.0.1"
+
+// fallbackAttempts 首选端口被占用时依次尝试的后续端口数，都不可用时由系统分配
+const fallbackAttempts = 10
+
+// Status 文件服务状态
+type Status struct {
+	Running bool   `json:"running"`         // 是否正在运行
+	Port    int    `json:"port"`            // 实际监听的端口
+	Error   string `json:"error,omitempty"` // 启动失败的原因
+}
+
 // HttpServer represents the HTTP file server
 type HttpServer struct {
-	port         int
-	httpServer   *http.Server
-	mutex        sync.Mutex
-	mediaHandler *handler.MediaHandler
+	host       string
+	port       int
+	startErr   error
+	httpServer *http.Server
+	mutex      sync.Mutex
+	urls       *handler.URLBuilder
+	thumbs     *thumb.Service
+	exports    *handler.ExportHandler
+	events     *event.Bus
+	routes     map[string]http.Handler // 按路径前缀挂载的其他服务，如 REST API
 }
 
-// NewHttpServer creates a new HttpServer instance
-func NewHttpServer(port int, mediaHandler *handler.MediaHandler) *HttpServer {
+// NewHttpServer creates a new HttpServer instance, serving the roots registered on urls
+// and streaming the events published on events
+func NewHttpServer(urls *handler.URLBuilder, thumbs *thumb.Service, exports *handler.ExportHandler, events *event.Bus) *HttpServer {
 	return &HttpServer{
-		port:         port,
-		mediaHandler: mediaHandler,
+		host:    defaultHost,
+		port:    urls.Port(),
+		urls:    urls,
+		thumbs:  thumbs,
+		exports: exports,
+		events:  events,
+		routes:  make(map[string]http.Handler),
+	}
+}
+
+// Handle mounts h on every path under prefix; requests still require the access token.
+// Must be called before Start
+func (hs *HttpServer) Handle(prefix string, h http.Handler) {
+	hs.mutex.Lock()
+	defer hs.mutex.Unlock()
+	hs.routes[prefix] = h
+}
+
+// BaseURL returns the address clients connect to, e.g. http://127.0.0.1:8080
+func (hs *HttpServer) BaseURL() string {
+	hs.mutex.Lock()
+	defer hs.mutex.Unlock()
+	return "http://" + net.JoinHostPort(hs.host, strconv.Itoa(hs.port))
+}
+
+// Token returns the access token clients must send with each request
+func (hs *HttpServer) Token() string {
+	return hs.urls.Token()
+}
+
+// route 返回路径所属的挂载服务
+func (hs *HttpServer) route(path string) (http.Handler, bool) {
+	hs.mutex.Lock()
+	defer hs.mutex.Unlock()
+	for prefix, h := range hs.routes {
+		if strings.HasPrefix(path, prefix) {
+			return h, true
+		}
 	}
+	return nil, false
 }
 
-// Start starts the HTTP server
-func (hs *HttpServer) Start() {
+// Start starts the HTTP server, falling back to another port when the
+// preferred one is busy, and updates the URL builder with the actual port
+func (hs *HttpServer) Start() error {
 	hs.Stop()
 
 	fileHandler := hs.fileHandler()
 
-	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", hs.port))
+	listener, err := hs.listen()
+	hs.mutex.Lock()
+	hs.startErr = err
+	hs.mutex.Unlock()
 	if err != nil {
 		logger.Error("HTTP server listen error", zap.Error(err))
-		return
+		return err
 	}
+	port := listener.Addr().(*net.TCPAddr).Port
 
 	server := &http.Server{
 		Handler: fileHandler,
@@ -49,15 +117,46 @@ func (hs *HttpServer) Start() {
 
 	hs.mutex.Lock()
 	hs.httpServer = server
+	if port != hs.port {
+		logger.Warn("HTTP server port is busy, using another one", zap.Int("preferred", hs.port), zap.Int("port", port))
+	}
+	hs.port = port
 	hs.mutex.Unlock()
+	hs.urls.SetPort(port)
 
-	logger.Info("HTTP server started", zap.Int("port", hs.port))
+	logger.Info("HTTP server started", zap.String("host", hs.host), zap.Int("port", port))
 
 	go func() {
 		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			logger.Error("HTTP server error", zap.Error(err))
 		}
 	}()
+	return nil
+}
+
+// listen 监听首选端口，被占用时依次尝试后续端口，最后由系统分配空闲端口
+func (hs *HttpServer) listen() (net.Listener, error) {
+	hs.mutex.Lock()
+	preferred := hs.port
+	hs.mutex.Unlock()
+
+	var ports []int
+	if preferred > 0 {
+		for i := 0; i <= fallbackAttempts && preferred+i <= 65535; i++ {
+			ports = append(ports, preferred+i)
+		}
+	}
+	ports = append(ports, 0)
+
+	var lastErr error
+	for _, port := range ports {
+		listener, err := net.Listen("tcp", net.JoinHostPort(hs.host, strconv.Itoa(port)))
+		if err == nil {
+			return listener, nil
+		}
+		lastErr = err
+	}
+	return nil, fmt.Errorf("无法启动文件服务：%w", lastErr)
 }
 
 // Stop stops the HTTP server
@@ -74,35 +173,154 @@ func (hs *HttpServer) Stop() {
 	}
 }
 
-// GetPort returns the HTTP server port
+// Status returns whether the server is running and why it failed to start
+func (hs *HttpServer) Status() Status {
+	hs.mutex.Lock()
+	defer hs.mutex.Unlock()
+	status := Status{Running: hs.httpServer != nil, Port: hs.port}
+	if hs.startErr != nil {
+		status.Error = hs.startErr.Error()
+	}
+	return status
+}
+
+// GetPort returns the port the HTTP server listens on
 func (hs *HttpServer) GetPort() int {
+	hs.mutex.Lock()
+	defer hs.mutex.Unlock()
 	return hs.port
 }
 
+// RegisterRoot allows the server to serve files under dir
+func (hs *HttpServer) RegisterRoot(dir string) (handler.Root, error) {
+	return hs.urls.RegisterRoot(dir)
+}
+
+// UnregisterRoot releases one registration of dir
+func (hs *HttpServer) UnregisterRoot(dir string) {
+	hs.urls.UnregisterRoot(dir)
+}
+
+// Roots returns the registered roots
+func (hs *HttpServer) Roots() []handler.Root {
+	return hs.urls.Roots()
+}
+
 func (hs *HttpServer) fileHandler() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		if origin := r.Header.Get("Origin"); allowedOrigin(origin) {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Vary", "Origin")
+		}
+		w.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, POST, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, Range, If-None-Match, If-Modified-Since, If-Range")
+		w.Header().Set("Access-Control-Expose-Headers", "Content-Range, Content-Length, Accept-Ranges, ETag, Last-Modified")
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
 			return
 		}
 
-		w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate, proxy-revalidate")
-		w.Header().Set("Pragma", "no-cache")
-		w.Header().Set("Expires", "0")
+		if !hs.authorized(r) {
+			http.Error(w, "访问令牌无效", http.StatusForbidden)
+			return
+		}
+
+		if h, ok := hs.route(r.URL.Path); ok {
+			h.ServeHTTP(w, r)
+			return
+		}
+		if strings.HasPrefix(r.URL.Path, thumb.URLPrefix) {
+			hs.serveThumb(w, r)
+			return
+		}
+		if r.URL.Path == EventsPath {
+			hs.serveEvents(w, r)
+			return
+		}
+		if strings.HasPrefix(r.URL.Path, handler.ExportPrefix) {
+			hs.serveExport(w, r)
+			return
+		}
 
-		dir := hs.mediaHandler.GetSelectedDir()
-		if dir == "" {
-			http.Error(w, "未选择文件目录", http.StatusForbidden)
+		path, ok := hs.resolve(w, r.URL.Path)
+		if !ok {
 			return
 		}
-		uri := strings.TrimPrefix(r.URL.Path, "/")
-		if uri == "" {
-			http.Error(w, "无效的文件路径", http.StatusBadRequest)
+		if resizeRequested(r.URL.Query()) {
+			hs.serveResized(w, r, path)
 			return
 		}
-		http.ServeFile(w, r, filepath.Join(dir, uri))
+		serveFile(w, r, path)
 	})
 }
+
+// resolve 将 /r/<根目录 ID>/<相对路径> 解析为已注册根目录下的文件路径，失败时写入错误响应
+func (hs *HttpServer) resolve(w http.ResponseWriter, uri string) (string, bool) {
+	id, rel, ok := strings.Cut(strings.TrimPrefix(uri, handler.RootPrefix), "/")
+	root, registered := hs.urls.Root(id)
+	if !strings.HasPrefix(uri, handler.RootPrefix) || !ok || !registered {
+		http.Error(w, "根目录未注册", http.StatusNotFound)
+		return "", false
+	}
+	path, err := resolvePath(root, rel)
+	if err != nil {
+		logger.Warn("拒绝访问文件", zap.String("uri", uri), zap.Error(err))
+		http.Error(w, "无效的文件路径", http.StatusForbidden)
+		return "", false
+	}
+	return path, true
+}
+
+// serveThumb 返回缩略图，路径为 /_thumb/<尺寸>/r/<根目录 ID>/<相对路径>，可通过 fmt=webp 指定格式
+func (hs *HttpServer) serveThumb(w http.ResponseWriter, r *http.Request) {
+	sizeText, uri, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, thumb.URLPrefix), "/")
+	size, err := strconv.Atoi(sizeText)
+	if !ok || err != nil || !thumb.ValidSize(size) {
+		http.Error(w, "无效的缩略图路径", http.StatusBadRequest)
+		return
+	}
+	format, err := imaging.ParseFormat(r.URL.Query().Get("fmt"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	src, ok := hs.resolve(w, "/"+uri)
+	if !ok {
+		return
+	}
+	path, err := hs.thumbs.Get(src, size, format)
+	if err != nil {
+		logger.Error("生成缩略图失败", zap.String("uri", uri), zap.Error(err))
+		http.Error(w, "生成缩略图失败", http.StatusNotFound)
+		return
+	}
+	// 缩略图随原图变化，缓存策略以原图的修改时间为准
+	if info, err := os.Stat(src); err == nil {
+		w.Header().Set("Cache-Control", cacheControl(r, info))
+	}
+	w.Header().Set("Content-Type", format.ContentType())
+	serveFile(w, r, path)
+}
+
+// authorized 请求是否携带本次启动的访问令牌，令牌可放在查询参数或 Authorization: Bearer 请求头中
+func (hs *HttpServer) authorized(r *http.Request) bool {
+	token := r.URL.Query().Get(handler.TokenParam)
+	if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && token == "" {
+		token = bearer
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(hs.urls.Token())) == 1
+}
+
+// allowedOrigin 是否允许跨域访问，只允许应用自身的页面（Wails 与本机开发服务器）
+func allowedOrigin(origin string) bool {
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	switch u.Hostname() {
+	case "wails", "wails.localhost", "localhost", "127.0.0.1":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,448 @@
+package server
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"media-app/internal/handler"
+	"media-app/pkg/event"
+	"media-app/pkg/logger"
+	"media-app/pkg/thumb"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	cfg := logger.DefaultConfig()
+	cfg.FileName = filepath.Join(os.TempDir(), "media-app-test", "app.log")
+	cfg.OutputConsole = false
+	if err := logger.Init(cfg); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// testServer 测试用的 HTTP 服务
+type testServer struct {
+	*httptest.Server
+	urls    *handler.URLBuilder
+	exports *handler.ExportHandler
+	events  *event.Bus
+	root    string // 所选目录的 URL 前缀
+}
+
+// newTestServer 创建以 root 为所选目录的 HTTP 服务
+func newTestServer(t *testing.T, root string) *testServer {
+	t.Helper()
+	urls := handler.NewURLBuilder(8080)
+	events := event.NewBus()
+	mh := handler.NewMediaHandler(urls, nil, events, t.TempDir())
+	mh.SetSelectedDir(root)
+	exports := handler.NewExportHandler(urls, events)
+	s := httptest.NewServer(NewHttpServer(urls, thumb.NewService(t.TempDir(), 2), exports, events).fileHandler())
+	t.Cleanup(s.Close)
+	return &testServer{Server: s, urls: urls, exports: exports, events: events, root: handler.RootPrefix + handler.RootID(root)}
+}
+
+// url 生成所选目录下带访问令牌的请求地址，query 为附加的查询参数
+func (s *testServer) url(path string, query ...string) string {
+	return s.rawURL(s.root+path, query...)
+}
+
+// rawURL 生成带访问令牌的请求地址，path 为完整的 URL 路径
+func (s *testServer) rawURL(path string, query ...string) string {
+	return s.URL + path + "?" + strings.Join(append(query, handler.TokenParam+"="+s.urls.Token()), "&")
+}
+
+// get 发送带请求头的 GET 请求
+func get(t *testing.T, url string, headers map[string]string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	assert.Nil(t, err)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	t.Cleanup(func() { _ = resp.Body.Close() })
+	return resp
+}
+
+func TestServeFileConditional(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "a.jpg")
+	assert.Nil(t, os.WriteFile(path, []byte("0123456789"), 0644))
+	modTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	assert.Nil(t, os.Chtimes(path, modTime, modTime))
+	s := newTestServer(t, root)
+
+	resp := get(t, s.url("/a.jpg"), nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "no-cache", resp.Header.Get("Cache-Control"))
+	assert.Equal(t, "bytes", resp.Header.Get("Accept-Ranges"))
+	tag := resp.Header.Get("ETag")
+	assert.NotEmpty(t, tag)
+	assert.Equal(t, modTime.UTC().Format(http.TimeFormat), resp.Header.Get("Last-Modified"))
+
+	// URL 中的修改时间与文件一致时允许长期缓存
+	resp = get(t, s.url("/a.jpg", fmt.Sprintf("t=%d", modTime.Unix())), nil)
+	assert.Contains(t, resp.Header.Get("Cache-Control"), "immutable")
+	resp = get(t, s.url("/a.jpg", "t=1"), nil)
+	assert.Equal(t, "no-cache", resp.Header.Get("Cache-Control"))
+
+	resp = get(t, s.url("/a.jpg"), map[string]string{"If-None-Match": tag})
+	assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+	resp = get(t, s.url("/a.jpg"), map[string]string{"If-Modified-Since": modTime.UTC().Format(http.TimeFormat)})
+	assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+
+	// If-Range 不匹配时返回完整内容
+	resp = get(t, s.url("/a.jpg"), map[string]string{"Range": "bytes=2-4", "If-Range": `"stale"`})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "0123456789", string(body))
+
+	resp = get(t, s.url("/a.jpg"), map[string]string{"Range": "bytes=2-4", "If-Range": tag})
+	assert.Equal(t, http.StatusPartialContent, resp.StatusCode)
+	body, _ = io.ReadAll(resp.Body)
+	assert.Equal(t, "234", string(body))
+
+	// 文件变化后旧的 ETag 失效
+	assert.Nil(t, os.WriteFile(path, []byte("changed"), 0644))
+	resp = get(t, s.url("/a.jpg"), map[string]string{"If-None-Match": tag})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = get(t, s.url("/missing.jpg"), nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestAccessControl(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	writeFile := func(path, content string) {
+		assert.Nil(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.Nil(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	writeFile(filepath.Join(root, "sub", "a.jpg"), "inside")
+	writeFile(filepath.Join(outside, "secret.jpg"), "secret")
+	assert.Nil(t, os.Symlink(filepath.Join(outside, "secret.jpg"), filepath.Join(root, "link.jpg")))
+	assert.Nil(t, os.Symlink(outside, filepath.Join(root, "linkdir")))
+	assert.Nil(t, os.Symlink(filepath.Join(root, "sub", "a.jpg"), filepath.Join(root, "alias.jpg")))
+	s := newTestServer(t, root)
+
+	resp := get(t, s.url("/sub/a.jpg"), nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = get(t, s.URL+s.root+"/sub/a.jpg", nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp = get(t, s.URL+s.root+"/sub/a.jpg?"+handler.TokenParam+"=wrong", nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	// 符号链接指向根目录之外时拒绝访问，指向根目录之内时允许
+	resp = get(t, s.url("/link.jpg"), nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp = get(t, s.url("/linkdir/secret.jpg"), nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp = get(t, s.url("/alias.jpg"), nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// 编码后的 .. 不能跳出根目录
+	resp = get(t, s.url("/sub/..%2f..%2f"+filepath.Base(outside)+"%2fsecret.jpg"), nil)
+	assert.NotEqual(t, http.StatusOK, resp.StatusCode)
+	path, err := resolvePath(root, "../"+filepath.Base(outside)+"/secret.jpg")
+	assert.Nil(t, err)
+	assert.True(t, within(root, path))
+
+	resp = get(t, s.url("/sub/a.jpg"), map[string]string{"Origin": "http://evil.example"})
+	assert.Empty(t, resp.Header.Get("Access-Control-Allow-Origin"))
+	resp = get(t, s.url("/sub/a.jpg"), map[string]string{"Origin": "wails://wails"})
+	assert.Equal(t, "wails://wails", resp.Header.Get("Access-Control-Allow-Origin"))
+}
+
+func TestServeFileRangeLarge(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "large.mp4")
+	const size = int64(5 << 30)
+	const tailOffset = size - 16
+
+	// 稀疏文件，只在 4GiB 之后与末尾写入数据
+	f, err := os.Create(path)
+	assert.Nil(t, err)
+	assert.Nil(t, f.Truncate(size))
+	_, err = f.WriteAt([]byte("beyond-4gib"), 1<<32)
+	assert.Nil(t, err)
+	_, err = f.WriteAt([]byte("end-of-the-file!"), tailOffset)
+	assert.Nil(t, err)
+	assert.Nil(t, f.Close())
+	s := newTestServer(t, root)
+
+	resp := get(t, s.url("/large.mp4"), map[string]string{"Range": fmt.Sprintf("bytes=%d-%d", int64(1<<32), int64(1<<32)+10)})
+	assert.Equal(t, http.StatusPartialContent, resp.StatusCode)
+	assert.Equal(t, fmt.Sprintf("bytes %d-%d/%d", int64(1<<32), int64(1<<32)+10, size), resp.Header.Get("Content-Range"))
+	assert.Equal(t, "11", resp.Header.Get("Content-Length"))
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "beyond-4gib", string(body))
+
+	// 后缀范围
+	resp = get(t, s.url("/large.mp4"), map[string]string{"Range": "bytes=-16"})
+	assert.Equal(t, http.StatusPartialContent, resp.StatusCode)
+	assert.Equal(t, fmt.Sprintf("bytes %d-%d/%d", tailOffset, size-1, size), resp.Header.Get("Content-Range"))
+	body, _ = io.ReadAll(resp.Body)
+	assert.Equal(t, "end-of-the-file!", string(body))
+
+	// 开放范围只读取开头部分即可验证
+	resp = get(t, s.url("/large.mp4"), map[string]string{"Range": fmt.Sprintf("bytes=%d-", tailOffset-5)})
+	assert.Equal(t, http.StatusPartialContent, resp.StatusCode)
+	assert.Equal(t, "21", resp.Header.Get("Content-Length"))
+
+	// 超出文件大小的范围
+	resp = get(t, s.url("/large.mp4"), map[string]string{"Range": fmt.Sprintf("bytes=%d-", size)})
+	assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, resp.StatusCode)
+	assert.Equal(t, fmt.Sprintf("bytes */%d", size), resp.Header.Get("Content-Range"))
+
+	// HEAD 请求返回完整长度而不传输内容
+	req, err := http.NewRequest(http.MethodHead, s.url("/large.mp4"), nil)
+	assert.Nil(t, err)
+	head, err := http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	_ = head.Body.Close()
+	assert.Equal(t, fmt.Sprint(size), head.Header.Get("Content-Length"))
+}
+
+func TestStartPortFallback(t *testing.T) {
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer busy.Close()
+	port := busy.Addr().(*net.TCPAddr).Port
+
+	urls := handler.NewURLBuilder(port)
+	hs := NewHttpServer(urls, nil, nil, nil)
+	assert.Nil(t, hs.Start())
+	defer hs.Stop()
+
+	status := hs.Status()
+	assert.True(t, status.Running)
+	assert.Empty(t, status.Error)
+	assert.NotEqual(t, port, status.Port)
+	assert.Equal(t, status.Port, urls.Port())
+	assert.Contains(t, urls.FileURL(t.TempDir(), "a.jpg", time.Now()), fmt.Sprintf("127.0.0.1:%d/", status.Port))
+
+	resp := get(t, fmt.Sprintf("http://127.0.0.1:%d/a.jpg", status.Port), nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
+
+func TestMultipleRoots(t *testing.T) {
+	root := t.TempDir()
+	other := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(root, "a.jpg"), []byte("selected"), 0644))
+	assert.Nil(t, os.WriteFile(filepath.Join(other, "a.jpg"), []byte("other"), 0644))
+	s := newTestServer(t, root)
+	hs := NewHttpServer(s.urls, nil, nil, nil)
+	otherURL := s.rawURL(handler.RootPrefix + handler.RootID(other) + "/a.jpg")
+
+	// 未注册的根目录不可访问，不再回退到所选目录
+	resp := get(t, otherURL, nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp = get(t, s.rawURL("/a.jpg"), nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	registered, err := hs.RegisterRoot(other)
+	assert.Nil(t, err)
+	assert.Equal(t, handler.RootID(other), registered.ID)
+	assert.Len(t, hs.Roots(), 2)
+	resp = get(t, otherURL, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "other", string(body))
+	resp = get(t, s.url("/a.jpg"), nil)
+	body, _ = io.ReadAll(resp.Body)
+	assert.Equal(t, "selected", string(body))
+
+	// 注册两次需要注销两次
+	_, err = hs.RegisterRoot(other)
+	assert.Nil(t, err)
+	hs.UnregisterRoot(other)
+	resp = get(t, otherURL, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	hs.UnregisterRoot(other)
+	resp = get(t, otherURL, nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	_, err = hs.RegisterRoot(filepath.Join(other, "a.jpg"))
+	assert.NotNil(t, err)
+}
+
+func TestServeResized(t *testing.T) {
+	root := t.TempDir()
+	f, err := os.Create(filepath.Join(root, "a.png"))
+	assert.Nil(t, err)
+	assert.Nil(t, png.Encode(f, image.NewRGBA(image.Rect(0, 0, 800, 600))))
+	assert.Nil(t, f.Close())
+	assert.Nil(t, os.WriteFile(filepath.Join(root, "a.txt"), []byte("text"), 0644))
+	s := newTestServer(t, root)
+
+	decode := func(resp *http.Response) []int {
+		img, _, err := image.Decode(resp.Body)
+		assert.Nil(t, err)
+		return []int{img.Bounds().Dx(), img.Bounds().Dy()}
+	}
+
+	resp := get(t, s.url("/a.png", "w=128", "h=128", "fit=cover", "q=70"), nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "image/jpeg", resp.Header.Get("Content-Type"))
+	assert.Equal(t, []int{128, 128}, decode(resp))
+	tag := resp.Header.Get("ETag")
+	assert.NotEmpty(t, tag)
+
+	resp = get(t, s.url("/a.png", "w=128", "h=128", "fit=cover", "q=70"), map[string]string{"If-None-Match": tag})
+	assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+
+	resp = get(t, s.url("/a.png", "w=400", "fmt=webp"), nil)
+	assert.Equal(t, "image/webp", resp.Header.Get("Content-Type"))
+	assert.NotEqual(t, tag, resp.Header.Get("ETag"))
+
+	// 尺寸向上取整到档位
+	resp = get(t, s.url("/a.png", "w=400"), nil)
+	assert.Equal(t, []int{512, 384}, decode(resp))
+
+	for _, query := range [][]string{{"w=abc"}, {"w=100", "fit=zoom"}, {"w=100", "fmt=gif"}, {"w=0"}} {
+		resp = get(t, s.url("/a.png", query...), nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, query)
+	}
+	resp = get(t, s.url("/a.txt", "w=100"), nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp = get(t, s.url("/missing.png", "w=100"), nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestServeExport(t *testing.T) {
+	root := t.TempDir()
+	var paths []string
+	for _, name := range []string{"a.jpg", "b.mp4"} {
+		path := filepath.Join(root, name)
+		assert.Nil(t, os.WriteFile(path, []byte(name), 0644))
+		paths = append(paths, path)
+	}
+	s := newTestServer(t, root)
+	// 下载地址指向测试服务
+	download := func(info handler.ExportInfo) string {
+		u, err := url.Parse(info.URL)
+		assert.Nil(t, err)
+		return s.URL + u.RequestURI()
+	}
+
+	info, err := s.exports.Prepare(handler.ExportRequest{Name: "第 1 组", Paths: paths})
+	assert.Nil(t, err)
+	assert.Equal(t, "第 1 组.zip", info.Name)
+	assert.Equal(t, 2, info.Files)
+
+	resp := get(t, s.URL+handler.ExportPrefix+info.ID, nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp = get(t, download(info), nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/zip", resp.Header.Get("Content-Type"))
+	assert.Contains(t, resp.Header.Get("Content-Disposition"), "UTF-8''%E7%AC%AC%201%20%E7%BB%84.zip")
+	body, _ := io.ReadAll(resp.Body)
+	zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	assert.Nil(t, err)
+	if assert.Len(t, zr.File, 2) {
+		assert.Equal(t, "a.jpg", zr.File[0].Name)
+		assert.Equal(t, "b.mp4", zr.File[1].Name)
+	}
+
+	// 下载地址只能使用一次
+	resp = get(t, download(info), nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// 开始前被取消的任务不可下载
+	info, err = s.exports.Prepare(handler.ExportRequest{Dir: root})
+	assert.Nil(t, err)
+	assert.Equal(t, "export.zip", info.Name)
+	s.exports.Cancel(info.ID)
+	resp = get(t, download(info), nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// 文件在下载前被删除时返回错误而不是不完整的 ZIP
+	info, err = s.exports.Prepare(handler.ExportRequest{Paths: paths})
+	assert.Nil(t, err)
+	assert.Nil(t, os.Remove(paths[0]))
+	resp = get(t, download(info), nil)
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get("Content-Disposition"))
+
+	_, err = s.exports.Prepare(handler.ExportRequest{})
+	assert.NotNil(t, err)
+}
+
+func TestHandleMounted(t *testing.T) {
+	urls := handler.NewURLBuilder(8080)
+	hs := NewHttpServer(urls, nil, nil, nil)
+	hs.Handle("/api/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.Method+" "+r.URL.Path)
+	}))
+	s := httptest.NewServer(hs.fileHandler())
+	t.Cleanup(s.Close)
+
+	resp := get(t, s.URL+"/api/ping", nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp = get(t, s.URL+"/api/ping", map[string]string{"Authorization": "Bearer wrong"})
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp = get(t, s.URL+"/api/ping", map[string]string{"Authorization": "Bearer " + hs.Token()})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "GET /api/ping", string(body))
+	resp = get(t, s.URL+"/api/ping?"+handler.TokenParam+"="+hs.Token(), nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// 未挂载的路径仍按文件处理
+	resp = get(t, s.URL+"/other?"+handler.TokenParam+"="+hs.Token(), nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestServeEvents(t *testing.T) {
+	s := newTestServer(t, t.TempDir())
+	resp := get(t, s.rawURL(EventsPath, "names=router,similar-loading"), nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	assert.Eventually(t, func() bool { return s.events.Subscribers() == 1 }, time.Second, 10*time.Millisecond)
+
+	s.events.Emit("media-count", 3)
+	s.events.Emit("router", "/similar")
+	s.events.Emit("similar-loading", true)
+
+	reader := bufio.NewReader(resp.Body)
+	var lines []string
+	for len(lines) < 7 {
+		line, err := reader.ReadString('\n')
+		assert.Nil(t, err)
+		if line = strings.TrimSuffix(line, "\n"); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	assert.Equal(t, []string{
+		"retry: 2000",
+		"id: 2", "event: router", `data: "/similar"`,
+		"id: 3", "event: similar-loading", "data: true",
+	}, lines)
+
+	// 客户端断开后取消订阅
+	_ = resp.Body.Close()
+	assert.Eventually(t, func() bool { return s.events.Subscribers() == 0 }, time.Second, 10*time.Millisecond)
+
+	resp = get(t, s.URL+EventsPath, nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+
+	"media-app/pkg/file"
+	"media-app/pkg/imaging"
+	"media-app/pkg/logger"
+	"media-app/pkg/thumb"
+
+	"go.uber.org/zap"
+)
+
+// resizeRequested 请求是否带有缩放参数 w 或 h
+func resizeRequested(query url.Values) bool {
+	return query.Has("w") || query.Has("h")
+}
+
+// parseResizeOptions 解析缩放参数：w、h 为目标宽高（向上取整到 thumb.SizeBuckets 的档位），fit 为 contain/cover/fill，fmt 为 jpeg/webp，q 为 JPEG 质量
+func parseResizeOptions(query url.Values) (thumb.Options, error) {
+	var opts thumb.Options
+	var err error
+	for _, param := range []struct {
+		name  string
+		value *int
+	}{{"w", &opts.Width}, {"h", &opts.Height}, {"q", &opts.Quality}} {
+		text := query.Get(param.name)
+		if text == "" {
+			continue
+		}
+		if *param.value, err = strconv.Atoi(text); err != nil {
+			return opts, fmt.Errorf("无效的参数 %s：%s", param.name, text)
+		}
+	}
+	if opts.Fit, err = imaging.ParseFitMode(query.Get("fit")); err != nil {
+		return opts, err
+	}
+	if opts.Format, err = imaging.ParseFormat(query.Get("fmt")); err != nil {
+		return opts, err
+	}
+	return opts, nil
+}
+
+// serveResized 返回缩放并重新编码后的图片，结果缓存在内存与磁盘中
+func (hs *HttpServer) serveResized(w http.ResponseWriter, r *http.Request, path string) {
+	if hs.thumbs == nil || file.GetFileTypeByExt(path) != file.MediaTypeImage {
+		http.Error(w, "该文件不支持缩放", http.StatusBadRequest)
+		return
+	}
+	opts, err := parseResizeOptions(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		http.Error(w, "文件不存在", http.StatusNotFound)
+		return
+	}
+
+	variant, err := hs.thumbs.Resize(path, opts)
+	if err != nil {
+		logger.Error("缩放图片失败", zap.String("path", path), zap.Error(err))
+		http.Error(w, "缩放图片失败", http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("ETag", fmt.Sprintf(`"%s"`, variant.Key))
+	w.Header().Set("Cache-Control", cacheControl(r, info))
+	w.Header().Set("Content-Type", variant.Format.ContentType())
+	http.ServeContent(w, r, "", variant.ModTime, bytes.NewReader(variant.Data))
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"embed"
+	"media-app/internal/api"
 	"media-app/internal/app"
 	"media-app/internal/menu"
 	"media-app/pkg/logger"
@@ -28,6 +29,7 @@ func main() {
 	logger.Info("应用启动中...")
 
 	app := app.New(8080)
+	app.HttpServer.Handle(api.Prefix, api.New(app))
 
 	err = wails.Run(&options.App{
 		Title:  "Media",
@@ -39,6 +41,7 @@ func main() {
 		BackgroundColour: options.NewRGBA(255, 255, 255, 0),
 		Menu:             menu.Create(app),
 		OnStartup:        app.Startup,
+		OnShutdown:       app.Shutdown,
 		Bind: []any{
 			app,
 		},
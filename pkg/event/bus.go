@@ -0,0 +1,101 @@
+// Package event 后端事件总线，事件同时转发给 Wails 前端与订阅者（如 SSE 客户端）
+package event
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Event 一条事件
+type Event struct {
+	ID   uint64    `json:"id"`   // 递增序号
+	Name string    `json:"name"` // 事件名，与 Wails 事件名一致
+	Data any       `json:"data"`
+	Time time.Time `json:"time"`
+}
+
+// subscriber 订阅者，names 为空时接收全部事件
+type subscriber struct {
+	ch    chan Event
+	names map[string]bool
+}
+
+// Bus 事件总线，零值不可用，nil 的 *Bus 上调用 Emit 会被忽略
+type Bus struct {
+	mux     sync.Mutex
+	seq     atomic.Uint64
+	subs    map[*subscriber]struct{}
+	forward func(name string, data any)
+}
+
+// NewBus 创建事件总线
+func NewBus() *Bus {
+	return &Bus{subs: make(map[*subscriber]struct{})}
+}
+
+// SetForward 设置事件的转发目标，如 Wails 的 runtime.EventsEmit
+func (b *Bus) SetForward(forward func(name string, data any)) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	b.forward = forward
+}
+
+// Emit 发送事件。订阅者的缓冲区已满时断开该订阅者（关闭其通道），避免拖慢发送方
+func (b *Bus) Emit(name string, data any) {
+	if b == nil {
+		return
+	}
+	e := Event{ID: b.seq.Add(1), Name: name, Data: data, Time: time.Now()}
+
+	b.mux.Lock()
+	forward := b.forward
+	for sub := range b.subs {
+		if len(sub.names) > 0 && !sub.names[name] {
+			continue
+		}
+		select {
+		case sub.ch <- e:
+		default:
+			delete(b.subs, sub)
+			close(sub.ch)
+		}
+	}
+	b.mux.Unlock()
+
+	if forward != nil {
+		forward(name, data)
+	}
+}
+
+// Subscribe 订阅事件，names 为空时订阅全部事件。
+// 返回的通道在取消订阅或处理过慢被断开时关闭，取消函数可重复调用
+func (b *Bus) Subscribe(buffer int, names ...string) (<-chan Event, func()) {
+	sub := &subscriber{ch: make(chan Event, max(buffer, 1))}
+	if len(names) > 0 {
+		sub.names = make(map[string]bool, len(names))
+		for _, name := range names {
+			sub.names[name] = true
+		}
+	}
+
+	b.mux.Lock()
+	b.subs[sub] = struct{}{}
+	b.mux.Unlock()
+
+	return sub.ch, func() {
+		b.mux.Lock()
+		defer b.mux.Unlock()
+		if _, ok := b.subs[sub]; ok {
+			delete(b.subs, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+// Subscribers 返回当前订阅者数量
+func (b *Bus) Subscribers() int {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	return len(b.subs)
+}
@@ -0,0 +1,54 @@
+package event
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBus(t *testing.T) {
+	bus := NewBus()
+	var forwarded []string
+	bus.SetForward(func(name string, _ any) { forwarded = append(forwarded, name) })
+
+	all, cancelAll := bus.Subscribe(4)
+	similar, cancelSimilar := bus.Subscribe(4, "similar-results")
+	bus.Emit("router", "/similar")
+	bus.Emit("similar-results", []int{1})
+
+	e := <-all
+	assert.Equal(t, "router", e.Name)
+	assert.Equal(t, "/similar", e.Data)
+	assert.Equal(t, "similar-results", (<-all).Name)
+	e = <-similar
+	assert.Equal(t, "similar-results", e.Name)
+	assert.Equal(t, uint64(2), e.ID)
+	assert.Empty(t, similar)
+	assert.Equal(t, []string{"router", "similar-results"}, forwarded)
+
+	// 取消订阅后通道关闭，重复取消无影响
+	cancelSimilar()
+	cancelSimilar()
+	_, ok := <-similar
+	assert.False(t, ok)
+	assert.Equal(t, 1, bus.Subscribers())
+	cancelAll()
+	assert.Equal(t, 0, bus.Subscribers())
+}
+
+func TestBusSlowSubscriber(t *testing.T) {
+	bus := NewBus()
+	slow, cancel := bus.Subscribe(1)
+	defer cancel()
+	bus.Emit("media-count", 1)
+	bus.Emit("media-count", 2)
+
+	// 缓冲区已满的订阅者被断开，已缓冲的事件仍可读取
+	assert.Equal(t, 1, (<-slow).Data)
+	_, ok := <-slow
+	assert.False(t, ok)
+	assert.Equal(t, 0, bus.Subscribers())
+
+	var nilBus *Bus
+	nilBus.Emit("ignored", nil)
+}
@@ -0,0 +1,334 @@
+// Package exif 纯 Go 实现的图片 EXIF 元数据读取，支持 JPEG、PNG、WebP 与 TIFF
+package exif
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// EXIF 标签
+const (
+	tagImageWidth         = 0x0100
+	tagImageLength        = 0x0101
+	tagMake               = 0x010F
+	tagModel              = 0x0110
+	tagOrientation        = 0x0112
+	tagDateTime           = 0x0132
+	tagExposureTime       = 0x829A
+	tagFNumber            = 0x829D
+	tagExifIFD            = 0x8769
+	tagISO                = 0x8827
+	tagGPSIFD             = 0x8825
+	tagDateTimeOriginal   = 0x9003
+	tagDateTimeDigit      = 0x9004
+	tagOffsetTimeOriginal = 0x9011
+	tagFocalLength        = 0x920A
+	tagPixelXDimension    = 0xA002
+	tagPixelYDimension    = 0xA003
+	tagLensMake           = 0xA433
+	tagLensModel          = 0xA434
+)
+
+// GPS 标签
+const (
+	tagGPSLatitudeRef  = 0x0001
+	tagGPSLatitude     = 0x0002
+	tagGPSLongitudeRef = 0x0003
+	tagGPSLongitude    = 0x0004
+	tagGPSAltitudeRef  = 0x0005
+	tagGPSAltitude     = 0x0006
+)
+
+// dateLayout EXIF 日期格式
+const dateLayout = "2006:01:02 15:04:05"
+
+// ErrNoExif 文件中不包含 EXIF 数据
+var ErrNoExif = errors.New("未找到 EXIF 数据")
+
+// Meta 图片 EXIF 元数据，缺失的字段为零值
+type Meta struct {
+	CaptureTime  time.Time `json:"captureTime"`            // 拍摄时间
+	Make         string    `json:"make,omitempty"`         // 相机厂商
+	Model        string    `json:"model,omitempty"`        // 相机型号
+	LensModel    string    `json:"lensModel,omitempty"`    // 镜头型号
+	ExposureTime float64   `json:"exposureTime,omitempty"` // 曝光时间（秒）
+	FNumber      float64   `json:"fNumber,omitempty"`      // 光圈值
+	FocalLength  float64   `json:"focalLength,omitempty"`  // 焦距（毫米）
+	ISO          int       `json:"iso,omitempty"`          // 感光度
+	Orientation  int       `json:"orientation,omitempty"`  // 方向，1-8
+	Width        int       `json:"width,omitempty"`        // EXIF 记录的像素宽度
+	Height       int       `json:"height,omitempty"`       // EXIF 记录的像素高度
+	GPS          *GPS      `json:"gps,omitempty"`          // 拍摄位置
+}
+
+// GPS 拍摄位置，经纬度为十进制度数，南纬与西经为负
+type GPS struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Altitude  float64 `json:"altitude,omitempty"` // 海拔（米），海平面以下为负
+}
+
+// Camera 返回相机名称，型号中已包含厂商时不重复
+func (m *Meta) Camera() string {
+	if m.Make == "" || strings.HasPrefix(strings.ToLower(m.Model), strings.ToLower(m.Make)) {
+		return m.Model
+	}
+	if m.Model == "" {
+		return m.Make
+	}
+	return m.Make + " " + m.Model
+}
+
+// ReadFile 读取 path 图片的 EXIF 元数据
+func ReadFile(path string) (*Meta, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开文件失败：%w", err)
+	}
+	defer f.Close()
+	return Decode(f)
+}
+
+// Decode 从 r 中读取 EXIF 元数据，支持 JPEG、PNG、WebP 与 TIFF
+func Decode(r io.ReaderAt) (*Meta, error) {
+	magic := make([]byte, 12)
+	if _, err := r.ReadAt(magic, 0); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("读取文件头失败：%w", err)
+	}
+
+	var blob []byte
+	var err error
+	switch {
+	case magic[0] == 0xFF && magic[1] == 0xD8:
+		blob, err = jpegExif(io.NewSectionReader(r, 0, 1<<62))
+	case bytes.HasPrefix(magic, pngSignature):
+		blob, err = pngExif(r)
+	case bytes.HasPrefix(magic, []byte("RIFF")) && bytes.Equal(magic[8:12], []byte("WEBP")):
+		blob, err = webpExif(r)
+	case bytes.HasPrefix(magic, []byte("II*\x00")) || bytes.HasPrefix(magic, []byte("MM\x00*")):
+		return parse(r, 0)
+	default:
+		return nil, ErrNoExif
+	}
+	if err != nil {
+		return nil, err
+	}
+	// PNG 与 WebP 中的 EXIF 有时也带有 JPEG 的 Exif 前缀
+	blob = bytes.TrimPrefix(blob, []byte("Exif\x00\x00"))
+	return parse(bytes.NewReader(blob), 0)
+}
+
+// parse 解析 base 处的 TIFF 结构
+func parse(r io.ReaderAt, base int64) (*Meta, error) {
+	t, offset, err := newTIFFReader(r, base)
+	if err != nil {
+		return nil, err
+	}
+	ifd0, _, err := t.readIFD(offset)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := &Meta{}
+	var exifIFD, gpsIFD ifd
+	if off, ok := t.uint(ifd0, tagExifIFD, 0); ok {
+		exifIFD, _, _ = t.readIFD(off)
+	}
+	if off, ok := t.uint(ifd0, tagGPSIFD, 0); ok {
+		gpsIFD, _, _ = t.readIFD(off)
+	}
+
+	loc := time.Local
+	if offset, err := time.Parse("-07:00", t.string(exifIFD, tagOffsetTimeOriginal)); err == nil {
+		_, seconds := offset.Zone()
+		loc = time.FixedZone("", seconds)
+	}
+	for _, date := range []string{
+		t.string(exifIFD, tagDateTimeOriginal),
+		t.string(exifIFD, tagDateTimeDigit),
+		t.string(ifd0, tagDateTime),
+	} {
+		if captured, err := time.ParseInLocation(dateLayout, date, loc); err == nil {
+			meta.CaptureTime = captured
+			break
+		}
+	}
+
+	meta.Make = t.string(ifd0, tagMake)
+	meta.Model = t.string(ifd0, tagModel)
+	meta.LensModel = t.string(exifIFD, tagLensModel)
+	if lensMake := t.string(exifIFD, tagLensMake); lensMake != "" && meta.LensModel != "" &&
+		!strings.HasPrefix(strings.ToLower(meta.LensModel), strings.ToLower(lensMake)) {
+		meta.LensModel = lensMake + " " + meta.LensModel
+	}
+	meta.ExposureTime, _ = t.rational(exifIFD, tagExposureTime, 0)
+	meta.FNumber, _ = t.rational(exifIFD, tagFNumber, 0)
+	meta.FocalLength, _ = t.rational(exifIFD, tagFocalLength, 0)
+	if iso, ok := t.uint(exifIFD, tagISO, 0); ok {
+		meta.ISO = int(iso)
+	}
+	if orientation, ok := t.uint(ifd0, tagOrientation, 0); ok && orientation >= 1 && orientation <= 8 {
+		meta.Orientation = int(orientation)
+	}
+
+	width, okW := t.uint(exifIFD, tagPixelXDimension, 0)
+	height, okH := t.uint(exifIFD, tagPixelYDimension, 0)
+	if !okW || !okH {
+		width, okW = t.uint(ifd0, tagImageWidth, 0)
+		height, okH = t.uint(ifd0, tagImageLength, 0)
+	}
+	if okW && okH {
+		meta.Width, meta.Height = int(width), int(height)
+	}
+
+	meta.GPS = t.gps(gpsIFD)
+	return meta, nil
+}
+
+// gps 解析 GPS IFD 中的经纬度与海拔
+func (t *tiffReader) gps(fields ifd) *GPS {
+	latitude, okLat := t.degrees(fields, tagGPSLatitude)
+	longitude, okLon := t.degrees(fields, tagGPSLongitude)
+	if !okLat || !okLon {
+		return nil
+	}
+	if strings.EqualFold(t.string(fields, tagGPSLatitudeRef), "S") {
+		latitude = -latitude
+	}
+	if strings.EqualFold(t.string(fields, tagGPSLongitudeRef), "W") {
+		longitude = -longitude
+	}
+	result := &GPS{Latitude: latitude, Longitude: longitude}
+	if altitude, ok := t.rational(fields, tagGPSAltitude, 0); ok {
+		// 海拔参考为 1 时表示海平面以下
+		if ref, ok := t.uint(fields, tagGPSAltitudeRef, 0); ok && ref == 1 {
+			altitude = -altitude
+		}
+		result.Altitude = altitude
+	}
+	return result
+}
+
+// degrees 将度、分、秒三个有理数换算为十进制度数
+func (t *tiffReader) degrees(fields ifd, tag uint16) (float64, bool) {
+	var result float64
+	for i, unit := range []float64{1, 60, 3600} {
+		v, ok := t.rational(fields, tag, i)
+		if !ok {
+			return 0, false
+		}
+		result += v / unit
+	}
+	return result, true
+}
+
+// jpegExif 在 JPEG 的 APP1 段中查找 EXIF 数据
+func jpegExif(r io.Reader) ([]byte, error) {
+	br := bufio.NewReader(r)
+	soi := make([]byte, 2)
+	if _, err := io.ReadFull(br, soi); err != nil {
+		return nil, fmt.Errorf("读取 JPEG 头失败：%w", err)
+	}
+
+	header := make([]byte, 4)
+	for {
+		if _, err := io.ReadFull(br, header[:2]); err != nil {
+			return nil, ErrNoExif
+		}
+		// 跳过填充字节
+		for header[0] == 0xFF && header[1] == 0xFF {
+			header[1], _ = br.ReadByte()
+		}
+		if header[0] != 0xFF {
+			return nil, ErrNoExif
+		}
+		marker := header[1]
+		// SOS 之后是图像数据，不会再有 EXIF
+		if marker == 0xDA || marker == 0xD9 {
+			return nil, ErrNoExif
+		}
+		if _, err := io.ReadFull(br, header[2:4]); err != nil {
+			return nil, ErrNoExif
+		}
+		size := int(binary.BigEndian.Uint16(header[2:4])) - 2
+		if size < 0 {
+			return nil, ErrNoExif
+		}
+		if marker != 0xE1 {
+			if _, err := br.Discard(size); err != nil {
+				return nil, ErrNoExif
+			}
+			continue
+		}
+
+		segment := make([]byte, size)
+		if _, err := io.ReadFull(br, segment); err != nil {
+			return nil, ErrNoExif
+		}
+		if bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return segment[6:], nil
+		}
+	}
+}
+
+// pngSignature PNG 文件签名
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// pngExif 查找 PNG 的 eXIf 块
+func pngExif(r io.ReaderAt) ([]byte, error) {
+	offset := int64(len(pngSignature))
+	header := make([]byte, 8)
+	for {
+		if _, err := r.ReadAt(header, offset); err != nil {
+			return nil, ErrNoExif
+		}
+		size := int64(binary.BigEndian.Uint32(header[:4]))
+		switch string(header[4:8]) {
+		case "eXIf":
+			if size > maxValueSize*4 {
+				return nil, ErrNoExif
+			}
+			return readChunk(r, offset+8, size)
+		case "IEND":
+			return nil, ErrNoExif
+		}
+		// 块数据之后是 4 字节 CRC
+		offset += 8 + size + 4
+	}
+}
+
+// webpExif 查找 WebP 的 EXIF 块
+func webpExif(r io.ReaderAt) ([]byte, error) {
+	offset := int64(12)
+	header := make([]byte, 8)
+	for {
+		if _, err := r.ReadAt(header, offset); err != nil {
+			return nil, ErrNoExif
+		}
+		size := int64(binary.LittleEndian.Uint32(header[4:8]))
+		if string(header[:4]) == "EXIF" {
+			if size > maxValueSize*4 {
+				return nil, ErrNoExif
+			}
+			return readChunk(r, offset+8, size)
+		}
+		// 奇数长度的块后有一个填充字节
+		offset += 8 + size + size%2
+	}
+}
+
+// readChunk 读取 offset 处 size 字节的数据
+func readChunk(r io.ReaderAt, offset, size int64) ([]byte, error) {
+	data := make([]byte, size)
+	if _, err := r.ReadAt(data, offset); err != nil {
+		return nil, fmt.Errorf("读取 EXIF 数据失败：%w", err)
+	}
+	return data, nil
+}
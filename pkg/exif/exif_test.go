@@ -0,0 +1,238 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testField 构造测试数据用的 IFD 字段
+type testField struct {
+	tag   uint16
+	typ   uint16
+	count uint32
+	data  []byte
+}
+
+// asciiField 构造 ASCII 字段
+func asciiField(tag uint16, s string) testField {
+	data := append([]byte(s), 0)
+	return testField{tag: tag, typ: typeASCII, count: uint32(len(data)), data: data}
+}
+
+// longField 构造 LONG 字段
+func longField(tag uint16, v uint32) testField {
+	data := binary.LittleEndian.AppendUint32(nil, v)
+	return testField{tag: tag, typ: typeLong, count: 1, data: data}
+}
+
+// shortField 构造 SHORT 字段
+func shortField(tag uint16, v uint16) testField {
+	data := binary.LittleEndian.AppendUint16(nil, v)
+	return testField{tag: tag, typ: typeShort, count: 1, data: data}
+}
+
+// rationalField 构造 RATIONAL 字段，values 依次为分子、分母
+func rationalField(tag uint16, values ...uint32) testField {
+	var data []byte
+	for _, v := range values {
+		data = binary.LittleEndian.AppendUint32(data, v)
+	}
+	return testField{tag: tag, typ: typeRational, count: uint32(len(values) / 2), data: data}
+}
+
+// buildTIFF 构造小端 TIFF 结构，sub 中的 IFD 通过 pointers 中对应标签链接到 IFD0
+func buildTIFF(ifd0 []testField, pointers map[uint16][]testField) []byte {
+	order := binary.LittleEndian
+	buf := &bytes.Buffer{}
+	buf.WriteString("II")
+	_ = binary.Write(buf, order, uint16(42))
+	_ = binary.Write(buf, order, uint32(8))
+
+	// 先计算各 IFD 的位置，子 IFD 依次写在 IFD0 之后
+	ifdSize := func(fields []testField) int {
+		size := 2 + len(fields)*12 + 4
+		for _, f := range fields {
+			if len(f.data) > 4 {
+				size += len(f.data)
+			}
+		}
+		return size
+	}
+	all := append([]testField(nil), ifd0...)
+	for tag := range pointers {
+		all = append(all, longField(tag, 0))
+	}
+	offset := 8 + ifdSize(all)
+	for i, f := range all {
+		if sub, ok := pointers[f.tag]; ok {
+			all[i] = longField(f.tag, uint32(offset))
+			offset += ifdSize(sub)
+		}
+	}
+
+	writeIFD := func(fields []testField) {
+		start := buf.Len()
+		dataOffset := start + 2 + len(fields)*12 + 4
+		var extra []byte
+		_ = binary.Write(buf, order, uint16(len(fields)))
+		for _, f := range fields {
+			_ = binary.Write(buf, order, f.tag)
+			_ = binary.Write(buf, order, f.typ)
+			_ = binary.Write(buf, order, f.count)
+			if len(f.data) <= 4 {
+				value := make([]byte, 4)
+				copy(value, f.data)
+				buf.Write(value)
+				continue
+			}
+			_ = binary.Write(buf, order, uint32(dataOffset+len(extra)))
+			extra = append(extra, f.data...)
+		}
+		_ = binary.Write(buf, order, uint32(0))
+		buf.Write(extra)
+	}
+	writeIFD(all)
+	for _, f := range all {
+		if sub, ok := pointers[f.tag]; ok {
+			writeIFD(sub)
+		}
+	}
+	return buf.Bytes()
+}
+
+// wrapJPEG 将 TIFF 结构包装为只包含 APP1 段的 JPEG
+func wrapJPEG(tiff []byte) []byte {
+	buf := &bytes.Buffer{}
+	buf.Write([]byte{0xFF, 0xD8})
+	// 一个无关的 APP0 段
+	buf.Write([]byte{0xFF, 0xE0, 0x00, 0x04, 0x00, 0x00})
+	buf.Write([]byte{0xFF, 0xE1})
+	_ = binary.Write(buf, binary.BigEndian, uint16(len(tiff)+8))
+	buf.WriteString("Exif\x00\x00")
+	buf.Write(tiff)
+	buf.Write([]byte{0xFF, 0xDA, 0x00, 0x02, 0xFF, 0xD9})
+	return buf.Bytes()
+}
+
+func TestDecodeCaptureTime(t *testing.T) {
+	tiff := buildTIFF(
+		[]testField{asciiField(tagDateTime, "2020:01:01 00:00:00")},
+		map[uint16][]testField{
+			tagExifIFD: {asciiField(tagDateTimeOriginal, "2024:05:06 07:08:09")},
+		},
+	)
+	expected := time.Date(2024, 5, 6, 7, 8, 9, 0, time.Local)
+
+	meta, err := Decode(bytes.NewReader(wrapJPEG(tiff)))
+	assert.Nil(t, err)
+	assert.True(t, expected.Equal(meta.CaptureTime))
+
+	meta, err = Decode(bytes.NewReader(tiff))
+	assert.Nil(t, err)
+	assert.True(t, expected.Equal(meta.CaptureTime))
+
+	_, err = Decode(bytes.NewReader([]byte{0xFF, 0xD8, 0xFF, 0xDA, 0x00, 0x02}))
+	assert.ErrorIs(t, err, ErrNoExif)
+}
+
+// wrapPNG 将 TIFF 结构包装为带 eXIf 块的 PNG（省略图像数据）
+func wrapPNG(tiff []byte) []byte {
+	buf := &bytes.Buffer{}
+	buf.Write(pngSignature)
+	chunk := func(typ string, data []byte) {
+		_ = binary.Write(buf, binary.BigEndian, uint32(len(data)))
+		buf.WriteString(typ)
+		buf.Write(data)
+		buf.Write([]byte{0, 0, 0, 0})
+	}
+	chunk("IHDR", make([]byte, 13))
+	chunk("eXIf", tiff)
+	chunk("IEND", nil)
+	return buf.Bytes()
+}
+
+// wrapWebP 将 TIFF 结构包装为带 EXIF 块的 WebP（省略图像数据）
+func wrapWebP(tiff []byte) []byte {
+	body := &bytes.Buffer{}
+	body.WriteString("WEBP")
+	chunk := func(typ string, data []byte) {
+		body.WriteString(typ)
+		_ = binary.Write(body, binary.LittleEndian, uint32(len(data)))
+		body.Write(data)
+		if len(data)%2 == 1 {
+			body.WriteByte(0)
+		}
+	}
+	chunk("VP8X", make([]byte, 9))
+	chunk("EXIF", append([]byte("Exif\x00\x00"), tiff...))
+
+	buf := &bytes.Buffer{}
+	buf.WriteString("RIFF")
+	_ = binary.Write(buf, binary.LittleEndian, uint32(body.Len()))
+	buf.Write(body.Bytes())
+	return buf.Bytes()
+}
+
+func TestDecodeMeta(t *testing.T) {
+	tiff := buildTIFF(
+		[]testField{
+			asciiField(tagMake, "Canon"),
+			asciiField(tagModel, "Canon EOS R5"),
+			shortField(tagOrientation, 6),
+		},
+		map[uint16][]testField{
+			tagExifIFD: {
+				asciiField(tagDateTimeOriginal, "2024:05:06 07:08:09"),
+				asciiField(tagOffsetTimeOriginal, "+08:00"),
+				rationalField(tagExposureTime, 1, 250),
+				rationalField(tagFNumber, 28, 10),
+				rationalField(tagFocalLength, 50, 1),
+				shortField(tagISO, 400),
+				asciiField(tagLensModel, "RF50mm F1.8 STM"),
+				longField(tagPixelXDimension, 8192),
+				longField(tagPixelYDimension, 5464),
+			},
+			tagGPSIFD: {
+				asciiField(tagGPSLatitudeRef, "N"),
+				rationalField(tagGPSLatitude, 31, 1, 12, 1, 36, 1),
+				asciiField(tagGPSLongitudeRef, "W"),
+				rationalField(tagGPSLongitude, 121, 1, 30, 1, 0, 1),
+				rationalField(tagGPSAltitude, 125, 10),
+			},
+		},
+	)
+
+	for name, data := range map[string][]byte{
+		"tiff": tiff,
+		"jpeg": wrapJPEG(tiff),
+		"png":  wrapPNG(tiff),
+		"webp": wrapWebP(tiff),
+	} {
+		meta, err := Decode(bytes.NewReader(data))
+		if !assert.Nil(t, err, name) {
+			continue
+		}
+		expected := time.Date(2024, 5, 6, 7, 8, 9, 0, time.FixedZone("", 8*3600))
+		assert.True(t, expected.Equal(meta.CaptureTime), name)
+		assert.Equal(t, "Canon EOS R5", meta.Camera(), name)
+		assert.Equal(t, "RF50mm F1.8 STM", meta.LensModel, name)
+		assert.InDelta(t, 0.004, meta.ExposureTime, 1e-9, name)
+		assert.InDelta(t, 2.8, meta.FNumber, 1e-9, name)
+		assert.InDelta(t, 50, meta.FocalLength, 1e-9, name)
+		assert.Equal(t, 400, meta.ISO, name)
+		assert.Equal(t, 6, meta.Orientation, name)
+		assert.Equal(t, []int{8192, 5464}, []int{meta.Width, meta.Height}, name)
+		if assert.NotNil(t, meta.GPS, name) {
+			assert.InDelta(t, 31.21, meta.GPS.Latitude, 1e-9, name)
+			assert.InDelta(t, -121.5, meta.GPS.Longitude, 1e-9, name)
+			assert.InDelta(t, 12.5, meta.GPS.Altitude, 1e-9, name)
+		}
+	}
+
+	_, err := Decode(bytes.NewReader(wrapPNG(nil)[:33]))
+	assert.ErrorIs(t, err, ErrNoExif)
+}
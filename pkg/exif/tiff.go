@@ -0,0 +1,189 @@
+package exif
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// TIFF 字段类型
+const (
+	typeByte      = 1
+	typeASCII     = 2
+	typeShort     = 3
+	typeLong      = 4
+	typeRational  = 5
+	typeSByte     = 6
+	typeUndefined = 7
+	typeSShort    = 8
+	typeSLong     = 9
+	typeSRational = 10
+)
+
+// typeSizes 各字段类型单个值占用的字节数
+var typeSizes = map[uint16]int64{
+	typeByte:      1,
+	typeASCII:     1,
+	typeShort:     2,
+	typeLong:      4,
+	typeRational:  8,
+	typeSByte:     1,
+	typeUndefined: 1,
+	typeSShort:    2,
+	typeSLong:     4,
+	typeSRational: 8,
+}
+
+// maxIFDEntries 单个 IFD 允许的最大条目数，防止损坏文件导致过量读取
+const maxIFDEntries = 1024
+
+// maxValueSize 单个字段允许的最大字节数
+const maxValueSize = 1 << 20
+
+// errNotTIFF 数据不是有效的 TIFF 结构
+var errNotTIFF = errors.New("无效的 TIFF 头")
+
+// entry IFD 中的一个字段
+type entry struct {
+	typ   uint16
+	count uint32
+	data  []byte
+}
+
+// ifd 一个 IFD 中的所有字段，按标签索引
+type ifd map[uint16]entry
+
+// tiffReader 按 TIFF 结构读取数据，偏移量相对于 TIFF 头
+type tiffReader struct {
+	r     io.ReaderAt
+	base  int64
+	order binary.ByteOrder
+}
+
+// newTIFFReader 解析 base 处的 TIFF 头
+func newTIFFReader(r io.ReaderAt, base int64) (*tiffReader, uint32, error) {
+	header := make([]byte, 8)
+	if _, err := r.ReadAt(header, base); err != nil {
+		return nil, 0, fmt.Errorf("读取 TIFF 头失败：%w", err)
+	}
+
+	var order binary.ByteOrder
+	switch string(header[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil, 0, errNotTIFF
+	}
+	if order.Uint16(header[2:4]) != 42 {
+		return nil, 0, errNotTIFF
+	}
+	return &tiffReader{r: r, base: base, order: order}, order.Uint32(header[4:8]), nil
+}
+
+// readIFD 读取 offset 处的 IFD，返回字段和下一个 IFD 的偏移
+func (t *tiffReader) readIFD(offset uint32) (ifd, uint32, error) {
+	buf := make([]byte, 2)
+	if _, err := t.r.ReadAt(buf, t.base+int64(offset)); err != nil {
+		return nil, 0, fmt.Errorf("读取 IFD 失败：%w", err)
+	}
+	count := int(t.order.Uint16(buf))
+	if count > maxIFDEntries {
+		return nil, 0, fmt.Errorf("IFD 条目数异常：%d", count)
+	}
+
+	raw := make([]byte, count*12+4)
+	if _, err := t.r.ReadAt(raw, t.base+int64(offset)+2); err != nil {
+		return nil, 0, fmt.Errorf("读取 IFD 条目失败：%w", err)
+	}
+
+	fields := make(ifd, count)
+	for i := 0; i < count; i++ {
+		e := raw[i*12 : i*12+12]
+		tag := t.order.Uint16(e[0:2])
+		typ := t.order.Uint16(e[2:4])
+		n := t.order.Uint32(e[4:8])
+
+		unit, ok := typeSizes[typ]
+		if !ok {
+			continue
+		}
+		size := unit * int64(n)
+		if size > maxValueSize {
+			continue
+		}
+
+		var data []byte
+		if size <= 4 {
+			data = append([]byte(nil), e[8:8+size]...)
+		} else {
+			data = make([]byte, size)
+			if _, err := t.r.ReadAt(data, t.base+int64(t.order.Uint32(e[8:12]))); err != nil {
+				continue
+			}
+		}
+		fields[tag] = entry{typ: typ, count: n, data: data}
+	}
+	return fields, t.order.Uint32(raw[count*12:]), nil
+}
+
+// string 读取 ASCII 字段
+func (t *tiffReader) string(fields ifd, tag uint16) string {
+	e, ok := fields[tag]
+	if !ok || (e.typ != typeASCII && e.typ != typeUndefined && e.typ != typeByte) {
+		return ""
+	}
+	s := string(e.data)
+	if i := strings.IndexByte(s, 0); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s)
+}
+
+// uint 读取整数字段的第 i 个值
+func (t *tiffReader) uint(fields ifd, tag uint16, i int) (uint32, bool) {
+	e, ok := fields[tag]
+	if !ok || uint32(i) >= e.count {
+		return 0, false
+	}
+	switch e.typ {
+	case typeByte, typeUndefined:
+		return uint32(e.data[i]), true
+	case typeShort:
+		return uint32(t.order.Uint16(e.data[i*2:])), true
+	case typeLong:
+		return t.order.Uint32(e.data[i*4:]), true
+	case typeSShort:
+		return uint32(int16(t.order.Uint16(e.data[i*2:]))), true
+	case typeSLong:
+		return uint32(int32(t.order.Uint32(e.data[i*4:]))), true
+	}
+	return 0, false
+}
+
+// rational 读取有理数字段的第 i 个值，分母为 0 时视为缺失
+func (t *tiffReader) rational(fields ifd, tag uint16, i int) (float64, bool) {
+	e, ok := fields[tag]
+	if !ok || uint32(i) >= e.count {
+		return 0, false
+	}
+	switch e.typ {
+	case typeRational:
+		num, den := t.order.Uint32(e.data[i*8:]), t.order.Uint32(e.data[i*8+4:])
+		if den == 0 {
+			return 0, false
+		}
+		return float64(num) / float64(den), true
+	case typeSRational:
+		num, den := int32(t.order.Uint32(e.data[i*8:])), int32(t.order.Uint32(e.data[i*8+4:]))
+		if den == 0 {
+			return 0, false
+		}
+		return float64(num) / float64(den), true
+	}
+	v, ok := t.uint(fields, tag, i)
+	return float64(v), ok
+}
@@ -0,0 +1,184 @@
+// Package export 将一组文件以 ZIP 格式流式写出，不生成临时文件
+package export
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// progressStep 单个文件写入过程中报告进度的间隔（字节）
+const progressStep = 8 << 20
+
+// Progress 导出进度
+type Progress struct {
+	Files      int    `json:"files"`      // 已写入的文件数
+	TotalFiles int    `json:"totalFiles"` // 文件总数
+	Bytes      int64  `json:"bytes"`      // 已写入的原文件字节数
+	TotalBytes int64  `json:"totalBytes"` // 原文件总字节数
+	Current    string `json:"current"`    // 正在写入的 ZIP 条目名
+}
+
+// entry 待写入的文件
+type entry struct {
+	path string
+	name string
+	info os.FileInfo
+}
+
+// Zip 将 files 依次写入 w，ctx 取消时停止并返回 ctx.Err()。
+// 媒体文件本身已经压缩，条目只存储不压缩；条目名为相对于所有文件公共父目录的路径。
+// progress 可以为 nil
+func Zip(ctx context.Context, w io.Writer, files []string, progress func(Progress)) error {
+	entries, err := collect(files)
+	if err != nil {
+		return err
+	}
+	p := Progress{TotalFiles: len(entries)}
+	for _, e := range entries {
+		p.TotalBytes += e.info.Size()
+	}
+	report := func() {
+		if progress != nil {
+			progress(p)
+		}
+	}
+	report()
+
+	zw := zip.NewWriter(w)
+	for _, e := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		p.Current = e.name
+		report()
+		if err := writeEntry(ctx, zw, e, func(n int64) {
+			p.Bytes += n
+			report()
+		}); err != nil {
+			return err
+		}
+		p.Files++
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("写入 ZIP 目录失败：%w", err)
+	}
+	p.Current = ""
+	report()
+	return nil
+}
+
+// collect 读取文件信息并计算条目名
+func collect(files []string) ([]entry, error) {
+	if len(files) == 0 {
+		return nil, fmt.Errorf("没有要导出的文件")
+	}
+	entries := make([]entry, 0, len(files))
+	for _, path := range files {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("读取文件失败：%w", err)
+		}
+		if !info.Mode().IsRegular() {
+			return nil, fmt.Errorf("不是普通文件：%s", path)
+		}
+		entries = append(entries, entry{path: path, info: info})
+	}
+
+	names := entryNames(files)
+	for i := range entries {
+		entries[i].name = names[i]
+	}
+	return entries, nil
+}
+
+// entryNames 以公共父目录为基准生成条目名，重名时追加序号
+func entryNames(files []string) []string {
+	base := filepath.Dir(files[0])
+	for _, path := range files[1:] {
+		for !within(base, path) {
+			parent := filepath.Dir(base)
+			if parent == base {
+				break
+			}
+			base = parent
+		}
+	}
+
+	used := make(map[string]bool)
+	names := make([]string, len(files))
+	for i, path := range files {
+		name, err := filepath.Rel(base, path)
+		if err != nil {
+			name = filepath.Base(path)
+		}
+		name = filepath.ToSlash(name)
+		ext := filepath.Ext(name)
+		stem := strings.TrimSuffix(name, ext)
+		for n := 1; used[strings.ToLower(name)]; n++ {
+			name = fmt.Sprintf("%s (%d)%s", stem, n, ext)
+		}
+		used[strings.ToLower(name)] = true
+		names[i] = name
+	}
+	return names
+}
+
+// within path 是否位于 dir 下
+func within(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// writeEntry 写入单个文件，每写入 progressStep 字节以及结束时调用 written
+func writeEntry(ctx context.Context, zw *zip.Writer, e entry, written func(int64)) error {
+	f, err := os.Open(e.path)
+	if err != nil {
+		return fmt.Errorf("打开文件失败：%w", err)
+	}
+	defer f.Close()
+
+	header, err := zip.FileInfoHeader(e.info)
+	if err != nil {
+		return fmt.Errorf("创建 ZIP 条目失败：%w", err)
+	}
+	header.Name = e.name
+	header.Method = zip.Store
+	dst, err := zw.CreateHeader(header)
+	if err != nil {
+		return fmt.Errorf("创建 ZIP 条目失败：%w", err)
+	}
+
+	for {
+		n, err := io.CopyN(dst, &contextReader{ctx: ctx, r: f}, progressStep)
+		if n > 0 {
+			written(n)
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			return fmt.Errorf("写入 %s 失败：%w", e.name, err)
+		}
+	}
+}
+
+// contextReader 每次读取前检查 ctx，使大文件的写入也能及时取消
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
@@ -0,0 +1,72 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestZip(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		filepath.Join(root, "2024", "a.jpg"):         "first",
+		filepath.Join(root, "2024", "trip", "b.mp4"): "second file",
+		filepath.Join(root, "2025", "a.jpg"):         "third",
+	}
+	for path, content := range files {
+		assert.Nil(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.Nil(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	paths := []string{
+		filepath.Join(root, "2024", "a.jpg"),
+		filepath.Join(root, "2024", "trip", "b.mp4"),
+		filepath.Join(root, "2025", "a.jpg"),
+	}
+
+	var buf bytes.Buffer
+	var last Progress
+	calls := 0
+	assert.Nil(t, Zip(context.Background(), &buf, paths, func(p Progress) {
+		last = p
+		calls++
+	}))
+	assert.Equal(t, Progress{Files: 3, TotalFiles: 3, Bytes: 21, TotalBytes: 21}, last)
+	assert.Greater(t, calls, 3)
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.Nil(t, err)
+	var names []string
+	for i, f := range zr.File {
+		names = append(names, f.Name)
+		assert.Equal(t, zip.Store, f.Method)
+		rc, err := f.Open()
+		assert.Nil(t, err)
+		content, _ := io.ReadAll(rc)
+		_ = rc.Close()
+		assert.Equal(t, files[paths[i]], string(content))
+	}
+	assert.Equal(t, []string{"2024/a.jpg", "2024/trip/b.mp4", "2025/a.jpg"}, names)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, Zip(ctx, io.Discard, paths, nil), context.Canceled)
+
+	assert.NotNil(t, Zip(context.Background(), io.Discard, nil, nil))
+	assert.NotNil(t, Zip(context.Background(), io.Discard, []string{filepath.Join(root, "missing.jpg")}, nil))
+	assert.NotNil(t, Zip(context.Background(), io.Discard, []string{root}, nil))
+}
+
+func TestEntryNames(t *testing.T) {
+	dir := filepath.Join("photos", "group")
+	a := filepath.Join(dir, "a.jpg")
+	assert.Equal(t, []string{"a.jpg"}, entryNames([]string{a}))
+	assert.Equal(t, []string{"a.jpg", "A (1).JPG", "a (2).jpg", "b.jpg"},
+		entryNames([]string{a, filepath.Join(dir, "A.JPG"), a, filepath.Join(dir, "b.jpg")}))
+	assert.Equal(t, []string{"group/a.jpg", "c.jpg"}, entryNames([]string{a, filepath.Join("photos", "c.jpg")}))
+}
@@ -0,0 +1,176 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// BatchResult 批量重命名中一个文件夹的结果
+type BatchResult struct {
+	Dir     string `json:"dir"`
+	Renamed int    `json:"renamed"`           // 重命名的文件数
+	Skipped bool   `json:"skipped,omitempty"` // 文件夹中没有需要重命名的文件
+	Error   string `json:"error,omitempty"`   // 失败原因
+}
+
+// BatchProgress 批量重命名进度
+type BatchProgress struct {
+	Total   int    `json:"total"`   // 文件夹总数
+	Done    int    `json:"done"`    // 已处理的文件夹数，包括失败的
+	Failed  int    `json:"failed"`  // 失败的文件夹数
+	Skipped int    `json:"skipped"` // 没有需要重命名的文件、跳过的文件夹数
+	Current string `json:"current"` // 最近开始处理的文件夹
+}
+
+// BatchSummary 批量重命名结果
+type BatchSummary struct {
+	BatchProgress
+	Canceled bool          `json:"canceled"` // 是否被取消，取消后未开始的文件夹不处理
+	Results  []BatchResult `json:"results"`  // 已处理文件夹的结果，按 dirs 的顺序
+}
+
+// BatchDirOptions 批量重命名的文件夹选择
+type BatchDirOptions struct {
+	Recursive bool     `json:"recursive"` // 是否处理各级子文件夹，否则只处理直接子文件夹
+	MaxDepth  int      `json:"maxDepth"`  // 递归的最大层级，直接子文件夹为第 1 层，0 表示不限制
+	Include   []string `json:"include"`   // 只处理名称匹配这些通配符的文件夹，不匹配的文件夹仍会继续向下查找
+	Exclude   []string `json:"exclude"`   // 跳过名称匹配这些通配符的文件夹及其子文件夹
+}
+
+// SubDirs 返回 root 下的直接子文件夹，跳过 .delete 等整理目录与隐藏目录
+func SubDirs(root string) ([]string, error) {
+	return BatchDirs(root, BatchDirOptions{})
+}
+
+// BatchDirs 按选项返回 root 下需要批量重命名的文件夹，不包括 root 本身，父文件夹在子文件夹之前。
+// 跳过 .delete 等整理目录与隐藏目录，不跟随符号链接
+func BatchDirs(root string, opts BatchDirOptions) ([]string, error) {
+	if opts.MaxDepth < 0 {
+		return nil, fmt.Errorf("最大层级不能为负数，当前为%d", opts.MaxDepth)
+	}
+	for _, pattern := range append(append([]string{}, opts.Include...), opts.Exclude...) {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("文件夹通配符无效：%s", pattern)
+		}
+	}
+	maxDepth := 1
+	if opts.Recursive {
+		maxDepth = opts.MaxDepth
+	}
+
+	var dirs []string
+	var walk func(dir string, depth int) error
+	walk = func(dir string, depth int) error {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return fmt.Errorf("读取目录失败：%w", err)
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if !entry.IsDir() || FilterDir(name) || matchAny(opts.Exclude, name) {
+				continue
+			}
+			path := filepath.Join(dir, name)
+			if len(opts.Include) == 0 || matchAny(opts.Include, name) {
+				dirs = append(dirs, path)
+			}
+			if maxDepth == 0 || depth < maxDepth {
+				if err := walk(path, depth+1); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := walk(root, 1); err != nil {
+		return nil, err
+	}
+	return dirs, nil
+}
+
+// matchAny 名称是否匹配任一通配符
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// BatchRename 使用 workers 个协程按 opts 重命名 dirs 中的各个文件夹，单个文件夹失败不影响其他文件夹，
+// 没有需要重命名的文件的文件夹（如只包含子文件夹）记为跳过。
+// 每个文件夹开始与结束时调用 onProgress；ctx 取消后不再开始新的文件夹，已开始的文件夹会执行完成
+func BatchRename(ctx context.Context, dirs []string, opts RenameOptions, workers int, onProgress func(BatchProgress)) BatchSummary {
+	if workers <= 0 {
+		workers = 1
+	}
+	summary := BatchSummary{BatchProgress: BatchProgress{Total: len(dirs)}}
+	results := make([]*BatchResult, len(dirs))
+
+	var mux sync.Mutex
+	report := func(update func(p *BatchProgress)) {
+		mux.Lock()
+		defer mux.Unlock()
+		update(&summary.BatchProgress)
+		if onProgress != nil {
+			onProgress(summary.BatchProgress)
+		}
+	}
+
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i, dir := range dirs {
+		select {
+		case <-ctx.Done():
+		case sem <- struct{}{}:
+		}
+		if ctx.Err() != nil {
+			summary.Canceled = true
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			report(func(p *BatchProgress) { p.Current = dir })
+
+			result := &BatchResult{Dir: dir}
+			plan, err := PlanRename(dir, opts)
+			if err == nil {
+				err = plan.Apply()
+			}
+			switch {
+			case errors.Is(err, ErrNoFiles):
+				result.Skipped = true
+			case err != nil:
+				result.Error = err.Error()
+			default:
+				result.Renamed = len(plan.Ops)
+			}
+			results[i] = result
+			report(func(p *BatchProgress) {
+				p.Done++
+				switch {
+				case result.Skipped:
+					p.Skipped++
+				case result.Error != "":
+					p.Failed++
+				}
+			})
+		}()
+	}
+	wg.Wait()
+
+	summary.Results = []BatchResult{}
+	for _, result := range results {
+		if result != nil {
+			summary.Results = append(summary.Results, *result)
+		}
+	}
+	return summary
+}
@@ -0,0 +1,87 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatchRename(t *testing.T) {
+	SetJournalDir(t.TempDir())
+	root := t.TempDir()
+	writeOrdered(t, root, "a/x.jpg", "a/y.jpg", "b/z.mp4", ".delete/old.jpg", "bad/c.jpg", "bad/d.jpg")
+	assert.Nil(t, os.Mkdir(filepath.Join(root, "empty"), 0755))
+
+	dirs, err := SubDirs(root)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{filepath.Join(root, "a"), filepath.Join(root, "b"), filepath.Join(root, "bad"), filepath.Join(root, "empty")}, dirs)
+
+	var mux sync.Mutex
+	var updates []BatchProgress
+	// 序号位数不足的文件夹失败，没有文件的文件夹跳过
+	summary := BatchRename(context.Background(), dirs, RenameOptions{Template: "{seq:1}{ext}", Start: startAt(9)}, 2, func(p BatchProgress) {
+		mux.Lock()
+		defer mux.Unlock()
+		updates = append(updates, p)
+	})
+	assert.False(t, summary.Canceled)
+	assert.Equal(t, BatchProgress{Total: 4, Done: 4, Failed: 2, Skipped: 1, Current: summary.Current}, summary.BatchProgress)
+	assert.Len(t, updates, 8)
+	assert.Equal(t, 4, updates[len(updates)-1].Done)
+	assert.Equal(t, []BatchResult{
+		{Dir: dirs[0], Error: summary.Results[0].Error},
+		{Dir: dirs[1], Renamed: 1},
+		{Dir: dirs[2], Error: summary.Results[2].Error},
+		{Dir: dirs[3], Skipped: true},
+	}, summary.Results)
+	assert.ErrorContains(t, errors.New(summary.Results[2].Error), "序号位数不足")
+	assert.Equal(t, []string{"9.mp4"}, dirNames(t, dirs[1]))
+	assert.Equal(t, []string{"old.jpg"}, dirNames(t, filepath.Join(root, ".delete")))
+
+	// 取消后不再开始新的文件夹
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	summary = BatchRename(ctx, dirs, RenameOptions{}, 2, nil)
+	assert.True(t, summary.Canceled)
+	assert.Empty(t, summary.Results)
+}
+
+func TestBatchDirs(t *testing.T) {
+	root := t.TempDir()
+	writeOrdered(t, root, "2023/01/trip/a.jpg", "2023/02/b.jpg", "2024/raw/c.jpg", "2024/05/d.jpg", ".delete/2023/e.jpg")
+	rel := func(dirs []string) []string {
+		var names []string
+		for _, dir := range dirs {
+			name, err := filepath.Rel(root, dir)
+			assert.Nil(t, err)
+			names = append(names, filepath.ToSlash(name))
+		}
+		return names
+	}
+
+	dirs, err := BatchDirs(root, BatchDirOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"2023", "2024"}, rel(dirs))
+
+	dirs, err = BatchDirs(root, BatchDirOptions{Recursive: true})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"2023", "2023/01", "2023/01/trip", "2023/02", "2024", "2024/05", "2024/raw"}, rel(dirs))
+
+	dirs, err = BatchDirs(root, BatchDirOptions{Recursive: true, MaxDepth: 2, Include: []string{"0?"}})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"2023/01", "2023/02", "2024/05"}, rel(dirs))
+
+	dirs, err = BatchDirs(root, BatchDirOptions{Recursive: true, Exclude: []string{"raw", "01"}})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"2023", "2023/02", "2024", "2024/05"}, rel(dirs))
+
+	_, err = BatchDirs(root, BatchDirOptions{Include: []string{"["}})
+	assert.NotNil(t, err)
+	_, err = BatchDirs(root, BatchDirOptions{Recursive: true, MaxDepth: -1})
+	assert.NotNil(t, err)
+}
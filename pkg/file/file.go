@@ -203,6 +203,11 @@ func FilterFile(fileName string) bool {
 	return filterFiles[fileName]
 }
 
+// FilterDir 是否过滤目录（.delete、.star 等整理目录以及隐藏目录）
+func FilterDir(dirName string) bool {
+	return strings.HasPrefix(dirName, ".") || filterFiles[dirName]
+}
+
 // getFinalTargetPath 生成最终目标路径（处理递增数字后缀逻辑）
 func getFinalTargetPath(baseNewPath string, suffix bool, maxTry int) (string, error) {
 	// 不开启重复后缀，直接校验并返回基础路径
@@ -0,0 +1,73 @@
+package file
+
+import (
+	"strings"
+	"unicode"
+)
+
+// NaturalLess 按自然顺序比较文件名，数字部分按数值比较（0002 < 0010 < 100）
+func NaturalLess(a, b string) bool {
+	a, b = strings.ToLower(a), strings.ToLower(b)
+	ra, rb := []rune(a), []rune(b)
+	i, j := 0, 0
+	for i < len(ra) && j < len(rb) {
+		if unicode.IsDigit(ra[i]) && unicode.IsDigit(rb[j]) {
+			// 提取连续数字
+			si, sj := i, j
+			for i < len(ra) && unicode.IsDigit(ra[i]) {
+				i++
+			}
+			for j < len(rb) && unicode.IsDigit(rb[j]) {
+				j++
+			}
+			if c := compareDigits(ra[si:i], rb[sj:j]); c != 0 {
+				return c < 0
+			}
+			continue
+		}
+		if ra[i] != rb[j] {
+			return ra[i] < rb[j]
+		}
+		i++
+		j++
+	}
+	if len(ra)-i != len(rb)-j {
+		return len(ra)-i < len(rb)-j
+	}
+	return a < b
+}
+
+// compareDigits 按数值比较两段数字，数值相同时前导零少的在前
+func compareDigits(a, b []rune) int {
+	ta := trimLeadingZeros(a)
+	tb := trimLeadingZeros(b)
+	if len(ta) != len(tb) {
+		if len(ta) < len(tb) {
+			return -1
+		}
+		return 1
+	}
+	for k := range ta {
+		if ta[k] != tb[k] {
+			if ta[k] < tb[k] {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case len(a) < len(b):
+		return -1
+	case len(a) > len(b):
+		return 1
+	}
+	return 0
+}
+
+// trimLeadingZeros 去除数字的前导零
+func trimLeadingZeros(digits []rune) []rune {
+	for len(digits) > 1 && digits[0] == '0' {
+		digits = digits[1:]
+	}
+	return digits
+}
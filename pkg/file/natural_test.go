@@ -0,0 +1,19 @@
+package file
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNaturalLess(t *testing.T) {
+	names := []string{"0010.jpg", "IMG_10.jpg", "100.jpg", "0002.jpg", "img_9.jpg", "2.jpg", "a.jpg"}
+	sort.Slice(names, func(i, j int) bool {
+		return NaturalLess(names[i], names[j])
+	})
+	assert.Equal(t, []string{"2.jpg", "0002.jpg", "0010.jpg", "100.jpg", "a.jpg", "img_9.jpg", "IMG_10.jpg"}, names)
+
+	assert.False(t, NaturalLess("0002.jpg", "0002.jpg"))
+	assert.True(t, NaturalLess("0002", "0002.jpg"))
+}
@@ -0,0 +1,229 @@
+package file
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// JournalPhase 重命名执行到的阶段
+type JournalPhase string
+
+const (
+	JournalPhaseTemp  JournalPhase = "temp"  // 正在将原文件重命名为临时文件，文件位于原文件名或临时文件名
+	JournalPhaseFinal JournalPhase = "final" // 正在将临时文件重命名为新文件名，文件位于临时文件名或新文件名
+)
+
+// RenameJournal 重命名日志，执行前写入，完成或回滚后删除。
+// 进程中断后根据日志继续执行或恢复原文件名
+type RenameJournal struct {
+	Dir       string       `json:"dir"`
+	Phase     JournalPhase `json:"phase"`
+	Ops       []RenameOp   `json:"ops"`
+	CreatedAt time.Time    `json:"createdAt"`
+}
+
+var (
+	// rename 重命名文件，测试时替换以模拟失败
+	rename = os.Rename
+
+	journalMux sync.RWMutex
+	journalDir = filepath.Join(os.TempDir(), "media-app-journals")
+)
+
+// SetJournalDir 设置重命名日志的保存目录，应用启动时设置为配置目录，重启后可以找到未完成的日志
+func SetJournalDir(dir string) {
+	journalMux.Lock()
+	defer journalMux.Unlock()
+	journalDir = dir
+}
+
+// journalPath 返回目录对应的日志文件路径
+func journalPath(dir string) string {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	sum := sha1.Sum([]byte(dir))
+	journalMux.RLock()
+	defer journalMux.RUnlock()
+	return filepath.Join(journalDir, hex.EncodeToString(sum[:])+".json")
+}
+
+// LoadJournal 读取目录未完成的重命名日志，没有时返回 nil
+func LoadJournal(dir string) (*RenameJournal, error) {
+	data, err := os.ReadFile(journalPath(dir))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取重命名日志失败：%w", err)
+	}
+	var journal RenameJournal
+	if err := json.Unmarshal(data, &journal); err != nil {
+		return nil, fmt.Errorf("解析重命名日志失败：%w", err)
+	}
+	return &journal, nil
+}
+
+// PendingJournals 返回所有未完成的重命名日志，按创建时间排序
+func PendingJournals() ([]RenameJournal, error) {
+	journalMux.RLock()
+	dir := journalDir
+	journalMux.RUnlock()
+
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return []RenameJournal{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取重命名日志目录失败：%w", err)
+	}
+	journals := []RenameJournal{}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		var journal RenameJournal
+		if json.Unmarshal(data, &journal) == nil && journal.Dir != "" {
+			journals = append(journals, journal)
+		}
+	}
+	sort.Slice(journals, func(i, j int) bool {
+		return journals[i].CreatedAt.Before(journals[j].CreatedAt)
+	})
+	return journals, nil
+}
+
+// save 写入日志，先写临时文件并同步到磁盘再替换，避免中断时留下不完整的日志
+func (j *RenameJournal) save() error {
+	path := journalPath(j.Dir)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建重命名日志目录失败：%w", err)
+	}
+	data, err := json.Marshal(j)
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), "journal-*")
+	if err != nil {
+		return fmt.Errorf("写入重命名日志失败：%w", err)
+	}
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return fmt.Errorf("写入重命名日志失败：%w", err)
+	}
+	return nil
+}
+
+// setPhase 更新执行阶段并写入日志
+func (j *RenameJournal) setPhase(phase JournalPhase) error {
+	j.Phase = phase
+	return j.save()
+}
+
+// remove 删除日志
+func (j *RenameJournal) remove() error {
+	if err := os.Remove(journalPath(j.Dir)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("删除重命名日志失败：%w", err)
+	}
+	return nil
+}
+
+// run 从当前阶段继续执行重命名，已完成的文件会被跳过，可以重复调用
+func (j *RenameJournal) run() error {
+	if j.Phase == JournalPhaseTemp {
+		for _, op := range j.Ops {
+			from := filepath.Join(j.Dir, op.From)
+			if !exists(from) {
+				continue
+			}
+			if err := rename(from, from+tempSuffix); err != nil {
+				return fmt.Errorf("临时重命名失败 %s -> %s：%w", from, from+tempSuffix, err)
+			}
+		}
+		if err := j.setPhase(JournalPhaseFinal); err != nil {
+			return err
+		}
+	}
+
+	for _, op := range j.Ops {
+		from, to := filepath.Join(j.Dir, op.From)+tempSuffix, filepath.Join(j.Dir, op.To)
+		if !exists(from) {
+			continue
+		}
+		if err := rename(from, to); err != nil {
+			return fmt.Errorf("最终重命名失败 %s -> %s：%w", from, to, err)
+		}
+	}
+	return j.remove()
+}
+
+// rollback 恢复原文件名，可以重复调用
+func (j *RenameJournal) rollback() error {
+	if j.Phase == JournalPhaseFinal {
+		// 已改为新文件名的文件先改回临时文件名，避免与其他文件的原文件名冲突
+		for _, op := range j.Ops {
+			to, temp := filepath.Join(j.Dir, op.To), filepath.Join(j.Dir, op.From)+tempSuffix
+			if exists(temp) || !exists(to) {
+				continue
+			}
+			if err := rename(to, temp); err != nil {
+				return fmt.Errorf("回滚失败 %s -> %s：%w", to, temp, err)
+			}
+		}
+		if err := j.setPhase(JournalPhaseTemp); err != nil {
+			return err
+		}
+	}
+
+	for _, op := range j.Ops {
+		from := filepath.Join(j.Dir, op.From)
+		if !exists(from + tempSuffix) {
+			continue
+		}
+		if err := rename(from+tempSuffix, from); err != nil {
+			return fmt.Errorf("回滚失败 %s -> %s：%w", from+tempSuffix, from, err)
+		}
+	}
+	return j.remove()
+}
+
+// Resume 继续执行中断的重命名
+func (j *RenameJournal) Resume() error {
+	if err := j.run(); err != nil {
+		return fmt.Errorf("继续重命名失败：%w", err)
+	}
+	return nil
+}
+
+// Revert 撤销中断的重命名，恢复原文件名
+func (j *RenameJournal) Revert() error {
+	return j.rollback()
+}
+
+// exists 路径是否存在
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
@@ -0,0 +1,103 @@
+package file
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// failRename 第 n 次重命名失败
+func failRename(t *testing.T, n int) {
+	t.Helper()
+	count := 0
+	rename = func(from, to string) error {
+		count++
+		if count == n {
+			return errors.New("模拟失败")
+		}
+		return os.Rename(from, to)
+	}
+	t.Cleanup(func() { rename = os.Rename })
+}
+
+func TestApplyRollback(t *testing.T) {
+	SetJournalDir(t.TempDir())
+	dir := t.TempDir()
+	writeOrdered(t, dir, "2.jpg", "1.jpg", "c.jpg")
+	plan, err := PlanOrderly(dir, 1)
+	assert.Nil(t, err)
+
+	// 第二阶段中途失败时恢复原文件名并删除日志
+	failRename(t, 5)
+	assert.ErrorContains(t, plan.Apply(), "已恢复原文件名")
+	assert.Equal(t, []string{"1.jpg", "2.jpg", "c.jpg"}, dirNames(t, dir))
+	data, err := os.ReadFile(filepath.Join(dir, "1.jpg"))
+	assert.Nil(t, err)
+	assert.Equal(t, "1.jpg", string(data))
+	journals, err := PendingJournals()
+	assert.Nil(t, err)
+	assert.Empty(t, journals)
+}
+
+func TestResumeJournal(t *testing.T) {
+	SetJournalDir(t.TempDir())
+	dir := t.TempDir()
+	writeOrdered(t, dir, "2.jpg", "1.jpg", "c.jpg")
+	plan, err := PlanOrderly(dir, 1)
+	assert.Nil(t, err)
+
+	// 第二阶段中途失败且回滚也失败，相当于进程中断，保留日志
+	count := 0
+	rename = func(from, to string) error {
+		if count++; count >= 5 {
+			return errors.New("模拟中断")
+		}
+		return os.Rename(from, to)
+	}
+	assert.ErrorContains(t, plan.Apply(), "重命名日志已保留")
+	rename = os.Rename
+
+	journals, err := PendingJournals()
+	assert.Nil(t, err)
+	assert.Len(t, journals, 1)
+	assert.Equal(t, dir, journals[0].Dir)
+	_, err = PlanOrderly(dir, 1)
+	assert.ErrorContains(t, err, "未完成的重命名")
+
+	assert.Nil(t, journals[0].Resume())
+	assert.Equal(t, []string{"1.jpg", "2.jpg", "3.jpg"}, dirNames(t, dir))
+	data, err := os.ReadFile(filepath.Join(dir, "1.jpg"))
+	assert.Nil(t, err)
+	assert.Equal(t, "2.jpg", string(data))
+	journal, err := LoadJournal(dir)
+	assert.Nil(t, err)
+	assert.Nil(t, journal)
+}
+
+func TestRevertJournal(t *testing.T) {
+	SetJournalDir(t.TempDir())
+	dir := t.TempDir()
+	writeOrdered(t, dir, "2.jpg", "1.jpg")
+	plan, err := PlanOrderly(dir, 1)
+	assert.Nil(t, err)
+
+	// 模拟第二阶段完成一个文件后中断：2.jpg 已改为 1.jpg，1.jpg 仍为临时文件
+	journal := &RenameJournal{Dir: dir, Phase: JournalPhaseFinal, Ops: plan.Ops}
+	assert.Nil(t, journal.save())
+	assert.Nil(t, os.Rename(filepath.Join(dir, "1.jpg"), filepath.Join(dir, "1.jpg"+tempSuffix)))
+	assert.Nil(t, os.Rename(filepath.Join(dir, "2.jpg"), filepath.Join(dir, "1.jpg")))
+
+	loaded, err := LoadJournal(dir)
+	assert.Nil(t, err)
+	assert.Nil(t, loaded.Revert())
+	assert.Equal(t, []string{"1.jpg", "2.jpg"}, dirNames(t, dir))
+	data, err := os.ReadFile(filepath.Join(dir, "2.jpg"))
+	assert.Nil(t, err)
+	assert.Equal(t, "2.jpg", string(data))
+	loaded, err = LoadJournal(dir)
+	assert.Nil(t, err)
+	assert.Nil(t, loaded)
+}
@@ -0,0 +1,216 @@
+package file
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// tempSuffix 两阶段重命名时临时文件的后缀
+const tempSuffix = ".tmp"
+
+// RenameOp 一个文件的重命名，文件名均为相对于 RenamePlan.Dir 的名称
+type RenameOp struct {
+	From    string    `json:"from"`    // 原文件名
+	To      string    `json:"to"`      // 新文件名
+	Size    int64     `json:"size"`    // 预览时的文件大小，执行前用于确认文件未变化
+	ModTime time.Time `json:"modTime"` // 预览时的修改时间
+}
+
+// RenameSkip 不参与重命名的文件
+type RenameSkip struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// RenameConflict 新文件名已被占用
+type RenameConflict struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Reason string `json:"reason"`
+}
+
+// RenamePlan 重命名计划，预览时不修改文件，Apply 严格按计划执行
+type RenamePlan struct {
+	Dir       string           `json:"dir"`
+	Ops       []RenameOp       `json:"ops"`       // 需要重命名的文件
+	Unchanged []string         `json:"unchanged"` // 名称已符合、无需重命名的文件
+	Skipped   []RenameSkip     `json:"skipped"`   // 不参与重命名的文件
+	Conflicts []RenameConflict `json:"conflicts"` // 存在冲突时计划不能执行
+}
+
+// PlanOrderly 生成 WithOrderly 的重命名计划：按修改时间从早到晚编号，length 为序号位数
+func PlanOrderly(dir string, length int) (*RenamePlan, error) {
+	// 前置参数校验
+	if dir == "" {
+		return nil, fmt.Errorf("目标目录不能为空")
+	}
+	if length <= 0 {
+		return nil, fmt.Errorf("序号位数必须大于0，当前为%d", length)
+	}
+	return PlanRename(dir, RenameOptions{Template: fmt.Sprintf("{seq:%d}{ext}", length)})
+}
+
+// newRenamePlan 根据新文件名生成计划，检查新文件名之间以及与目录中其他条目的冲突，
+// skipped 为调用方排除、保持不变的文件。文件名比较不区分大小写，以兼容 macOS 与 Windows 的文件系统
+func newRenamePlan(dir string, metaList []Meta, names []string, skipped []RenameSkip) (*RenamePlan, error) {
+	if err := checkJournal(dir); err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("读取目录失败：%w", err)
+	}
+	plan := &RenamePlan{
+		Dir:       dir,
+		Ops:       []RenameOp{},
+		Unchanged: []string{},
+		Skipped:   append([]RenameSkip{}, skipped...),
+		Conflicts: []RenameConflict{},
+	}
+
+	renamed := make(map[string]bool, len(metaList))
+	for _, meta := range metaList {
+		renamed[strings.ToLower(meta.FileName)] = true
+	}
+	// 目录中不参与重命名的条目占用的名称
+	occupied := make(map[string]string)
+	for _, entry := range entries {
+		name := entry.Name()
+		if renamed[strings.ToLower(name)] {
+			continue
+		}
+		occupied[strings.ToLower(name)] = name
+		if !entry.IsDir() && FilterFile(name) {
+			plan.Skipped = append(plan.Skipped, RenameSkip{Name: name, Reason: "系统文件"})
+		}
+	}
+
+	targets := make(map[string]string, len(names))
+	for i, meta := range metaList {
+		to := names[i]
+		key := strings.ToLower(to)
+		switch {
+		case strings.TrimSuffix(to, meta.Ext) == "" || strings.HasPrefix(to, "."):
+			plan.Conflicts = append(plan.Conflicts, RenameConflict{From: meta.FileName, To: to, Reason: "新文件名无效"})
+		case occupied[key] != "":
+			plan.Conflicts = append(plan.Conflicts, RenameConflict{From: meta.FileName, To: to, Reason: "已存在同名的 " + occupied[key]})
+		case targets[key] != "":
+			plan.Conflicts = append(plan.Conflicts, RenameConflict{From: meta.FileName, To: to, Reason: "与 " + targets[key] + " 的新文件名相同"})
+		}
+		targets[key] = meta.FileName
+
+		info, err := os.Stat(meta.FullPath)
+		if err != nil {
+			return nil, fmt.Errorf("获取文件信息失败 %s %w", meta.FileName, err)
+		}
+		if to == meta.FileName {
+			plan.Unchanged = append(plan.Unchanged, to)
+			continue
+		}
+		plan.Ops = append(plan.Ops, RenameOp{From: meta.FileName, To: to, Size: info.Size(), ModTime: info.ModTime()})
+	}
+	return plan, nil
+}
+
+// Apply 严格按计划执行重命名。执行前确认计划中的文件未变化、新文件名未被其他文件占用，
+// 先将文件重命名为临时文件再改为新文件名，避免新旧文件名互相覆盖。
+// 执行前写入重命名日志，出错时自动恢复原文件名；进程中断时可通过 PendingJournals 继续或撤销
+func (p *RenamePlan) Apply() error {
+	if len(p.Conflicts) > 0 {
+		return fmt.Errorf("重命名计划存在 %d 处冲突，无法执行", len(p.Conflicts))
+	}
+	if err := checkJournal(p.Dir); err != nil {
+		return err
+	}
+	if err := p.verify(); err != nil {
+		return err
+	}
+	if len(p.Ops) == 0 {
+		return nil
+	}
+
+	journal := &RenameJournal{Dir: p.Dir, Phase: JournalPhaseTemp, Ops: p.Ops, CreatedAt: time.Now()}
+	if err := journal.save(); err != nil {
+		return err
+	}
+	if err := journal.run(); err != nil {
+		if rollbackErr := journal.rollback(); rollbackErr != nil {
+			return fmt.Errorf("%w；%v，重命名日志已保留，可稍后继续或撤销", err, rollbackErr)
+		}
+		return fmt.Errorf("%w，已恢复原文件名", err)
+	}
+	return nil
+}
+
+// checkJournal 目录存在未完成的重命名时返回错误
+func checkJournal(dir string) error {
+	journal, err := LoadJournal(dir)
+	if err != nil {
+		return err
+	}
+	if journal != nil {
+		return fmt.Errorf("目录存在未完成的重命名，请先继续或撤销：%s", dir)
+	}
+	return nil
+}
+
+// verify 确认计划仍然有效：文件名均为 Dir 下的有效名称且互不重复，原文件未变化，
+// 临时文件名与新文件名未被计划外的文件占用。计划可能来自客户端，不依赖 Conflicts 是否完整
+func (p *RenamePlan) verify() error {
+	sources := make(map[string]bool, len(p.Ops))
+	for _, op := range p.Ops {
+		if !isBaseName(op.From) {
+			return fmt.Errorf("原文件名无效：%s", op.From)
+		}
+		key := strings.ToLower(op.From)
+		if sources[key] {
+			return fmt.Errorf("原文件重复：%s", op.From)
+		}
+		sources[key] = true
+	}
+	targets := make(map[string]bool, len(p.Ops))
+	for _, op := range p.Ops {
+		if !isBaseName(op.To) || strings.HasPrefix(op.To, ".") {
+			return fmt.Errorf("新文件名无效：%s", op.To)
+		}
+		key := strings.ToLower(op.To)
+		if targets[key] {
+			return fmt.Errorf("新文件名重复：%s", op.To)
+		}
+		targets[key] = true
+	}
+	for _, op := range p.Ops {
+		if targets[strings.ToLower(op.From+tempSuffix)] {
+			return fmt.Errorf("新文件名与临时文件名冲突：%s", op.From+tempSuffix)
+		}
+	}
+
+	for _, op := range p.Ops {
+		info, err := os.Stat(filepath.Join(p.Dir, op.From))
+		if err != nil {
+			return fmt.Errorf("文件已不存在，请重新预览：%s", op.From)
+		}
+		if info.Size() != op.Size || !info.ModTime().Equal(op.ModTime) {
+			return fmt.Errorf("文件已变化，请重新预览：%s", op.From)
+		}
+		if _, err := os.Lstat(filepath.Join(p.Dir, op.From+tempSuffix)); !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("临时文件已存在：%s", op.From+tempSuffix)
+		}
+		if sources[strings.ToLower(op.To)] {
+			continue
+		}
+		if _, err := os.Lstat(filepath.Join(p.Dir, op.To)); !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("目标文件已存在，请重新预览：%s", op.To)
+		}
+	}
+	return nil
+}
+
+// isBaseName 名称是否为不含路径的文件名
+func isBaseName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\`) && filepath.Base(name) == name
+}
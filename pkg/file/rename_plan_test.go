@@ -0,0 +1,143 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeOrdered 写入文件，修改时间按参数顺序递增
+func writeOrdered(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	for i, name := range names {
+		path := filepath.Join(dir, name)
+		assert.Nil(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.Nil(t, os.WriteFile(path, []byte(name), 0644))
+		modTime := base.Add(time.Duration(i) * time.Minute)
+		assert.Nil(t, os.Chtimes(path, modTime, modTime))
+	}
+}
+
+// dirNames 返回目录中的文件名（不含子目录），已排序
+func dirNames(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	assert.Nil(t, err)
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestPlanOrderly(t *testing.T) {
+	dir := t.TempDir()
+	writeOrdered(t, dir, "c.jpg", "0002.mp4", "a.png", ".DS_Store")
+
+	plan, err := PlanOrderly(dir, 4)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"0002.mp4"}, plan.Unchanged)
+	assert.Equal(t, []RenameSkip{{Name: ".DS_Store", Reason: "系统文件"}}, plan.Skipped)
+	assert.Empty(t, plan.Conflicts)
+	var ops [][2]string
+	for _, op := range plan.Ops {
+		ops = append(ops, [2]string{op.From, op.To})
+	}
+	assert.Equal(t, [][2]string{{"c.jpg", "0001.jpg"}, {"a.png", "0003.png"}}, ops)
+	// 预览不修改文件
+	assert.Equal(t, []string{".DS_Store", "0002.mp4", "a.png", "c.jpg"}, dirNames(t, dir))
+
+	assert.Nil(t, plan.Apply())
+	assert.Equal(t, []string{".DS_Store", "0001.jpg", "0002.mp4", "0003.png"}, dirNames(t, dir))
+
+	_, err = PlanOrderly(dir, 0)
+	assert.NotNil(t, err)
+	_, err = PlanOrderly(t.TempDir(), 4)
+	assert.NotNil(t, err)
+}
+
+func TestPlanOrderlySwap(t *testing.T) {
+	// 新旧文件名互换时通过临时文件完成
+	dir := t.TempDir()
+	writeOrdered(t, dir, "2.jpg", "1.jpg")
+	plan, err := PlanOrderly(dir, 1)
+	assert.Nil(t, err)
+	assert.Len(t, plan.Ops, 2)
+	assert.Nil(t, plan.Apply())
+	data, err := os.ReadFile(filepath.Join(dir, "1.jpg"))
+	assert.Nil(t, err)
+	assert.Equal(t, "2.jpg", string(data))
+}
+
+func TestPlanOrderlyConflict(t *testing.T) {
+	dir := t.TempDir()
+	writeOrdered(t, dir, "a.jpg", "b.jpg")
+	assert.Nil(t, os.Mkdir(filepath.Join(dir, "02.jpg"), 0755))
+
+	plan, err := PlanOrderly(dir, 2)
+	assert.Nil(t, err)
+	assert.Len(t, plan.Conflicts, 1)
+	assert.Equal(t, "b.jpg", plan.Conflicts[0].From)
+	assert.NotNil(t, plan.Apply())
+	assert.Equal(t, []string{"a.jpg", "b.jpg"}, dirNames(t, dir))
+	assert.NotNil(t, WithOrderly(dir, 2))
+}
+
+func TestApplyStalePlan(t *testing.T) {
+	dir := t.TempDir()
+	writeOrdered(t, dir, "a.jpg", "b.jpg")
+	plan, err := PlanOrderly(dir, 2)
+	assert.Nil(t, err)
+
+	// 预览后文件被修改或新增了占用新文件名的文件时拒绝执行
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "a.jpg"), []byte("changed"), 0644))
+	assert.ErrorContains(t, plan.Apply(), "文件已变化")
+
+	plan, err = PlanOrderly(dir, 2)
+	assert.Nil(t, err)
+	writeOrdered(t, dir, "01.jpg")
+	assert.ErrorContains(t, plan.Apply(), "目标文件已存在")
+	assert.Equal(t, []string{"01.jpg", "a.jpg", "b.jpg"}, dirNames(t, dir))
+}
+
+func TestApplyTamperedPlan(t *testing.T) {
+	dir := t.TempDir()
+	writeOrdered(t, dir, "a.jpg", "b.jpg", "c.jpg")
+	tests := []struct {
+		name   string
+		tamper func(plan *RenamePlan)
+		err    string
+	}{
+		{"重复的新文件名", func(plan *RenamePlan) { plan.Ops[1].To = "01.JPG" }, "新文件名重复"},
+		{"跳出目录", func(plan *RenamePlan) { plan.Ops[0].To = "../x.jpg" }, "新文件名无效"},
+		{"原文件名含路径", func(plan *RenamePlan) { plan.Ops[0].From = "sub/a.jpg" }, "原文件名无效"},
+		{"与临时文件名冲突", func(plan *RenamePlan) { plan.Ops[2].To = "a.jpg.tmp" }, "临时文件名冲突"},
+		{"隐藏文件", func(plan *RenamePlan) { plan.Ops[0].To = ".x" }, "新文件名无效"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := PlanOrderly(dir, 2)
+			assert.Nil(t, err)
+			tt.tamper(plan)
+			assert.ErrorContains(t, plan.Apply(), tt.err)
+			assert.Equal(t, []string{"a.jpg", "b.jpg", "c.jpg"}, dirNames(t, dir))
+		})
+	}
+
+	// 去掉冲突列表后仍然拒绝执行
+	assert.Nil(t, os.Mkdir(filepath.Join(dir, "02.jpg"), 0755))
+	plan, err := PlanOrderly(dir, 2)
+	assert.Nil(t, err)
+	assert.Len(t, plan.Conflicts, 1)
+	plan.Conflicts = nil
+	assert.ErrorContains(t, plan.Apply(), "目标文件已存在")
+	assert.Equal(t, []string{"a.jpg", "b.jpg", "c.jpg"}, dirNames(t, dir))
+}
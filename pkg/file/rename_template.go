@@ -0,0 +1,310 @@
+package file
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"media-app/pkg/exif"
+)
+
+// DefaultRenameTemplate 默认重命名模板，等同于 WithOrderly(dir, 4)
+const DefaultRenameTemplate = "{seq:4}{ext}"
+
+// ErrNoFiles 目录中没有需要重命名的文件
+var ErrNoFiles = errors.New("文件数量为 0")
+
+// RenameSort 重命名编号的排序方式
+type RenameSort string
+
+const (
+	RenameSortModTime     RenameSort = "modTime"     // 修改时间
+	RenameSortCaptureTime RenameSort = "captureTime" // EXIF 拍摄时间，没有时使用修改时间
+	RenameSortName        RenameSort = "name"        // 原文件名（自然顺序）
+)
+
+// RenameOptions 模板重命名选项
+//
+// 模板支持的变量：
+//
+//	{seq:N}        序号，N 为补零位数，{seq} 不补零
+//	{date:layout}  拍摄时间，没有 EXIF 时使用修改时间，layout 为 Go 时间格式，默认 20060102
+//	{mtime:layout} 修改时间
+//	{camera}       相机型号，没有 EXIF 时为 unknown
+//	{parent}       所在文件夹名称
+//	{orig}         原文件名（不含后缀）
+//	{ext}          原文件后缀（含 .）
+type RenameOptions struct {
+	Template    string      `json:"template"`    // 为空时使用 DefaultRenameTemplate
+	SortBy      RenameSort  `json:"sortBy"`      // 为空时按修改时间
+	Start       *int        `json:"start"`       // 起始序号，可以为 0，为空时从 1 开始
+	AutoWidth   bool        `json:"autoWidth"`   // 序号位数不足时自动增加，而不是返回错误
+	Types       []MediaType `json:"types"`       // 只重命名这些类型的文件，其他文件保持不变；为空时重命名全部文件
+	SeparateSeq bool        `json:"separateSeq"` // 每种类型单独编号，如图片与视频各自从起始序号开始
+}
+
+// templateToken 模板片段，name 为空时是普通文本
+type templateToken struct {
+	text string
+	name string
+	arg  string
+}
+
+// renameTemplate 解析后的模板
+type renameTemplate struct {
+	tokens   []templateToken
+	seqWidth int  // {seq:N} 中最大的 N
+	useExif  bool // 需要读取 EXIF
+}
+
+// invalidNameChars 文件名中不允许出现的字符
+const invalidNameChars = `/\:*?"<>|`
+
+// parseTemplate 解析重命名模板
+func parseTemplate(tpl string) (*renameTemplate, error) {
+	if strings.ContainsAny(tpl, `/\`) {
+		return nil, fmt.Errorf("模板不能包含路径分隔符：%s", tpl)
+	}
+	t := &renameTemplate{}
+	for rest := tpl; rest != ""; {
+		start := strings.IndexByte(rest, '{')
+		if start < 0 {
+			start = len(rest)
+		}
+		if start > 0 {
+			// 普通文本在预览时校验，避免执行时才在 Windows 或 exFAT 上失败
+			text := rest[:start]
+			if strings.ContainsAny(text, invalidNameChars) {
+				return nil, fmt.Errorf("模板不能包含以下字符 %s：%s", invalidNameChars, tpl)
+			}
+			t.tokens = append(t.tokens, templateToken{text: text})
+		}
+		if start == len(rest) {
+			break
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("模板缺少 }：%s", tpl)
+		}
+		name, arg, _ := strings.Cut(rest[start+1:start+end], ":")
+		token := templateToken{name: name, arg: arg}
+		switch name {
+		case "seq":
+			if arg != "" {
+				width, err := strconv.Atoi(arg)
+				if err != nil || width <= 0 {
+					return nil, fmt.Errorf("序号位数无效：{%s:%s}", name, arg)
+				}
+				t.seqWidth = max(t.seqWidth, width)
+			}
+		case "date", "mtime":
+			if token.arg == "" {
+				token.arg = "20060102"
+			}
+			t.useExif = t.useExif || name == "date"
+		case "camera":
+			t.useExif = true
+		case "parent", "orig", "ext":
+		default:
+			return nil, fmt.Errorf("模板变量不支持：{%s}", name)
+		}
+		t.tokens = append(t.tokens, token)
+		rest = rest[start+end+1:]
+	}
+	if len(t.tokens) == 0 {
+		return nil, fmt.Errorf("模板不能为空")
+	}
+	return t, nil
+}
+
+// templateMeta 渲染模板所需的文件信息
+type templateMeta struct {
+	Meta
+	captureTime time.Time // 没有 EXIF 时为修改时间
+	camera      string
+}
+
+// render 生成第 seq 个文件的新文件名，width 大于 {seq:N} 的 N 时按 width 补零
+func (t *renameTemplate) render(meta templateMeta, seq, width int, parent string) string {
+	var b strings.Builder
+	for _, token := range t.tokens {
+		switch token.name {
+		case "":
+			b.WriteString(token.text)
+		case "seq":
+			if token.arg == "" {
+				b.WriteString(strconv.Itoa(seq))
+			} else {
+				n, _ := strconv.Atoi(token.arg)
+				fmt.Fprintf(&b, "%0*d", max(n, width), seq)
+			}
+		case "date":
+			b.WriteString(sanitizeName(meta.captureTime.Format(token.arg)))
+		case "mtime":
+			b.WriteString(sanitizeName(meta.ModTime.Format(token.arg)))
+		case "camera":
+			b.WriteString(sanitizeName(meta.camera))
+		case "parent":
+			b.WriteString(sanitizeName(parent))
+		case "orig":
+			b.WriteString(sanitizeName(strings.TrimSuffix(meta.FileName, meta.Ext)))
+		case "ext":
+			b.WriteString(meta.Ext)
+		}
+	}
+	return b.String()
+}
+
+// sanitizeName 替换文件名中不允许出现的字符
+func sanitizeName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(invalidNameChars, r) {
+			return '-'
+		}
+		return r
+	}, strings.TrimSpace(name))
+}
+
+// PlanRename 按模板生成 dir 下文件的重命名计划，预览时不修改文件
+func PlanRename(dir string, opts RenameOptions) (*RenamePlan, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("目标目录不能为空")
+	}
+	if opts.Template == "" {
+		opts.Template = DefaultRenameTemplate
+	}
+	start := 1
+	if opts.Start != nil {
+		start = *opts.Start
+	}
+	if start < 0 {
+		return nil, fmt.Errorf("起始序号不能为负数，当前为%d", start)
+	}
+	tpl, err := parseTemplate(opts.Template)
+	if err != nil {
+		return nil, err
+	}
+
+	// 获取目录下有效文件元数据，不在所选类型中的文件保持不变
+	allMetas, err := GetFileMetas(dir)
+	if err != nil {
+		return nil, fmt.Errorf("获取文件元数据失败：%w", err)
+	}
+	var skipped []RenameSkip
+	groups := make(map[MediaType][]templateMeta)
+	var groupOrder []MediaType
+	for _, meta := range allMetas {
+		mediaType := GetFileTypeByExt(meta.FileName)
+		if len(opts.Types) > 0 && !slices.Contains(opts.Types, mediaType) {
+			skipped = append(skipped, RenameSkip{Name: meta.FileName, Reason: "不是所选类型"})
+			continue
+		}
+		key := MediaType("")
+		if opts.SeparateSeq {
+			key = mediaType
+		}
+		if _, ok := groups[key]; !ok {
+			groupOrder = append(groupOrder, key)
+		}
+		groups[key] = append(groups[key], newTemplateMeta(meta, mediaType, tpl.useExif || opts.SortBy == RenameSortCaptureTime))
+	}
+	fileCount := len(allMetas) - len(skipped)
+	if fileCount == 0 {
+		return nil, fmt.Errorf("无需排序，%w, path: %s ", ErrNoFiles, dir)
+	}
+	// 分组编号时按所选类型的顺序，未指定时图片在视频之前
+	order := opts.Types
+	if len(order) == 0 {
+		order = []MediaType{MediaTypeImage, MediaTypeVideo}
+	}
+	sort.SliceStable(groupOrder, func(i, j int) bool {
+		return typeRank(order, groupOrder[i]) < typeRank(order, groupOrder[j])
+	})
+
+	// 校验序号位数是否足够容纳最大序号，AutoWidth 时增加位数
+	width := 0
+	if tpl.seqWidth > 0 {
+		largest := 0
+		for _, group := range groups {
+			largest = max(largest, len(group))
+		}
+		last := start + largest - 1
+		digitCount := len(strconv.Itoa(last))
+		switch {
+		case opts.AutoWidth:
+			width = digitCount
+		case digitCount > tpl.seqWidth:
+			return nil, fmt.Errorf("序号位数不足：文件数量为%d（最大序号 %d 需%d位），当前指定位数为%d",
+				largest, last, digitCount, tpl.seqWidth)
+		}
+	}
+
+	parent := filepath.Base(dir)
+	metaList := make([]Meta, 0, fileCount)
+	names := make([]string, 0, fileCount)
+	for _, key := range groupOrder {
+		metas := groups[key]
+		if err := sortTemplateMetas(metas, opts.SortBy); err != nil {
+			return nil, err
+		}
+		for i, meta := range metas {
+			metaList = append(metaList, meta.Meta)
+			names = append(names, tpl.render(meta, start+i, width, parent))
+		}
+	}
+	return newRenamePlan(dir, metaList, names, skipped)
+}
+
+// newTemplateMeta 读取渲染模板所需的文件信息，readExif 时读取图片的拍摄时间与相机型号
+func newTemplateMeta(meta Meta, mediaType MediaType, readExif bool) templateMeta {
+	m := templateMeta{Meta: meta, captureTime: meta.ModTime, camera: "unknown"}
+	if !readExif || mediaType != MediaTypeImage {
+		return m
+	}
+	if info, err := exif.ReadFile(meta.FullPath); err == nil {
+		if !info.CaptureTime.IsZero() {
+			m.captureTime = info.CaptureTime
+		}
+		if camera := info.Camera(); camera != "" {
+			m.camera = camera
+		}
+	}
+	return m
+}
+
+// typeRank 返回类型在 order 中的位置，不在其中的类型排在最后
+func typeRank(order []MediaType, mediaType MediaType) int {
+	if i := slices.Index(order, mediaType); i >= 0 {
+		return i
+	}
+	return len(order)
+}
+
+// sortTemplateMetas 按排序方式排序，值相同时按文件名排序，保证预览与执行一致
+func sortTemplateMetas(metas []templateMeta, sortBy RenameSort) error {
+	var key func(m templateMeta) time.Time
+	switch sortBy {
+	case "", RenameSortModTime:
+		key = func(m templateMeta) time.Time { return m.ModTime }
+	case RenameSortCaptureTime:
+		key = func(m templateMeta) time.Time { return m.captureTime }
+	case RenameSortName:
+		sort.SliceStable(metas, func(i, j int) bool { return NaturalLess(metas[i].FileName, metas[j].FileName) })
+		return nil
+	default:
+		return fmt.Errorf("排序方式不支持：%s", sortBy)
+	}
+	sort.SliceStable(metas, func(i, j int) bool {
+		ti, tj := key(metas[i]), key(metas[j])
+		if !ti.Equal(tj) {
+			return ti.Before(tj)
+		}
+		return NaturalLess(metas[i].FileName, metas[j].FileName)
+	})
+	return nil
+}
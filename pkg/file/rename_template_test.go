@@ -0,0 +1,116 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// planNames 返回计划中的新文件名，按原文件名索引
+func planNames(plan *RenamePlan) map[string]string {
+	names := make(map[string]string)
+	for _, op := range plan.Ops {
+		names[op.From] = op.To
+	}
+	for _, name := range plan.Unchanged {
+		names[name] = name
+	}
+	return names
+}
+
+// startAt 返回起始序号的指针
+func startAt(n int) *int {
+	return &n
+}
+
+func TestParseTemplate(t *testing.T) {
+	tpl, err := parseTemplate("{date:2006-01-02}_{camera}_{seq:3}{ext}")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, tpl.seqWidth)
+	assert.True(t, tpl.useExif)
+
+	for _, bad := range []string{"", "{seq:0}", "{seq:x}", "{unknown}", "{seq", "a/{seq}", "{seq:3}:a?{ext}", "a*{seq}", `{seq}"`} {
+		_, err := parseTemplate(bad)
+		assert.NotNil(t, err, bad)
+	}
+
+	// 原文件名中不允许的字符同样被替换
+	tpl, err = parseTemplate("{orig}_{seq:2}{ext}")
+	assert.Nil(t, err)
+	meta := templateMeta{Meta: Meta{FileName: "a:b?.jpg", Ext: ".jpg"}}
+	assert.Equal(t, "a-b-_07.jpg", tpl.render(meta, 7, 0, "dir"))
+}
+
+func TestPlanRename(t *testing.T) {
+	SetJournalDir(t.TempDir())
+	dir := filepath.Join(t.TempDir(), "trip")
+	writeOrdered(t, dir, "b.jpg", "a.mp4", "c.png")
+	modTime := time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local)
+	assert.Nil(t, os.Chtimes(filepath.Join(dir, "c.png"), modTime, modTime))
+
+	plan, err := PlanRename(dir, RenameOptions{Template: "{parent}_{mtime:2006}_{seq:2}_{orig}{ext}", Start: startAt(7)})
+	assert.Nil(t, err)
+	year := time.Now().Add(-time.Hour).Format("2006")
+	assert.Equal(t, map[string]string{
+		"c.png": "trip_2024_07_c.png",
+		"b.jpg": "trip_" + year + "_08_b.jpg",
+		"a.mp4": "trip_" + year + "_09_a.mp4",
+	}, planNames(plan))
+
+	// 按文件名排序，没有 EXIF 时相机为 unknown、拍摄时间为修改时间
+	plan, err = PlanRename(dir, RenameOptions{Template: "{seq}-{camera}-{date:0102}{ext}", SortBy: RenameSortName})
+	assert.Nil(t, err)
+	assert.Equal(t, "1-unknown-"+time.Now().Add(-time.Hour).Format("0102")+".mp4", planNames(plan)["a.mp4"])
+	assert.Equal(t, "3-unknown-0501.png", planNames(plan)["c.png"])
+
+	// 没有序号时新文件名相同的文件记为冲突
+	plan, err = PlanRename(dir, RenameOptions{Template: "{parent}.jpg"})
+	assert.Nil(t, err)
+	assert.Len(t, plan.Conflicts, 2)
+
+	// 起始序号可以为 0，不能为负数
+	plan, err = PlanRename(dir, RenameOptions{Template: "{seq:1}{ext}", SortBy: RenameSortName, Start: startAt(0)})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"a.mp4": "0.mp4", "b.jpg": "1.jpg", "c.png": "2.png"}, planNames(plan))
+	_, err = PlanRename(dir, RenameOptions{Start: startAt(-1)})
+	assert.ErrorContains(t, err, "不能为负数")
+
+	_, err = PlanRename(dir, RenameOptions{Template: "{seq:1}{ext}", Start: startAt(8)})
+	assert.ErrorContains(t, err, "序号位数不足")
+	_, err = PlanRename(dir, RenameOptions{SortBy: "size"})
+	assert.NotNil(t, err)
+}
+
+func TestPlanRenameTypes(t *testing.T) {
+	SetJournalDir(t.TempDir())
+	dir := t.TempDir()
+	writeOrdered(t, dir, "a.jpg", "notes.txt", "b.mp4", "c.png", "d.mov")
+
+	// 只编号图片与视频，其他文件保持不变
+	plan, err := PlanRename(dir, RenameOptions{Template: "{seq:1}{ext}", Types: []MediaType{MediaTypeImage, MediaTypeVideo}})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"a.jpg": "1.jpg", "b.mp4": "2.mp4", "c.png": "3.png", "d.mov": "4.mov"}, planNames(plan))
+	assert.Equal(t, []RenameSkip{{Name: "notes.txt", Reason: "不是所选类型"}}, plan.Skipped)
+
+	// 图片与视频各自编号
+	plan, err = PlanRename(dir, RenameOptions{Template: "{seq:2}{ext}", Types: []MediaType{MediaTypeVideo, MediaTypeImage}, SeparateSeq: true})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"a.jpg": "01.jpg", "c.png": "02.png", "b.mp4": "01.mp4", "d.mov": "02.mov"}, planNames(plan))
+	assert.Equal(t, "b.mp4", plan.Ops[0].From)
+
+	// 位数不足时自动增加
+	_, err = PlanRename(dir, RenameOptions{Template: "{seq:1}{ext}", Start: startAt(8)})
+	assert.ErrorContains(t, err, "序号位数不足")
+	plan, err = PlanRename(dir, RenameOptions{Template: "IMG_{seq:1}{ext}", Start: startAt(8), AutoWidth: true})
+	assert.Nil(t, err)
+	assert.Equal(t, "IMG_08.jpg", planNames(plan)["a.jpg"])
+	assert.Equal(t, "IMG_12.mov", planNames(plan)["d.mov"])
+
+	assert.Nil(t, plan.Apply())
+	assert.Equal(t, []string{"IMG_08.jpg", "IMG_09.txt", "IMG_10.mp4", "IMG_11.png", "IMG_12.mov"}, dirNames(t, dir))
+	_, err = PlanRename(dir, RenameOptions{Types: []MediaType{MediaTypeAudio}})
+	assert.ErrorContains(t, err, "文件数量为 0")
+}
@@ -0,0 +1,32 @@
+// Package imaging 图片解码与尺寸读取
+package imaging
+
+import (
+	"fmt"
+	"image"
+	"os"
+
+	// 注册图片解码器
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
+)
+
+// Size 读取图片的像素尺寸，只解析文件头不解码像素
+func Size(path string) (int, int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, fmt.Errorf("打开文件失败：%w", err)
+	}
+	defer f.Close()
+
+	cfg, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return 0, 0, fmt.Errorf("读取图片尺寸失败：%w", err)
+	}
+	return cfg.Width, cfg.Height, nil
+}
@@ -0,0 +1,82 @@
+package imaging
+
+import (
+	"image"
+	"image/draw"
+
+	"media-app/pkg/exif"
+)
+
+// Orientation 读取图片的 EXIF 方向，没有 EXIF 或读取失败时返回 1（正常方向）
+func Orientation(path string) int {
+	meta, err := exif.ReadFile(path)
+	if err != nil || meta.Orientation < 1 || meta.Orientation > 8 {
+		return 1
+	}
+	return meta.Orientation
+}
+
+// SwapsAxes 方向 5-8 需要旋转 90 度，显示时宽高互换
+func SwapsAxes(orientation int) bool {
+	return orientation >= 5 && orientation <= 8
+}
+
+// DecodeOriented 解码图片并按 EXIF 方向旋转为正常显示的方向
+func DecodeOriented(path string) (image.Image, error) {
+	img, err := Decode(path)
+	if err != nil {
+		return nil, err
+	}
+	return Orient(img, Orientation(path)), nil
+}
+
+// Orient 按 EXIF 方向（1-8）翻转或旋转图片，使其成为正常显示的方向
+func Orient(img image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+	src := toRGBA(img)
+	width, height := src.Rect.Dx(), src.Rect.Dy()
+	dstWidth, dstHeight := width, height
+	if SwapsAxes(orientation) {
+		dstWidth, dstHeight = height, width
+	}
+
+	// 对目标图片的每个像素求出它在原图中的位置
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	for y := 0; y < dstHeight; y++ {
+		for x := 0; x < dstWidth; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // 水平翻转
+				sx, sy = width-1-x, y
+			case 3: // 旋转 180 度
+				sx, sy = width-1-x, height-1-y
+			case 4: // 垂直翻转
+				sx, sy = x, height-1-y
+			case 5: // 沿左上-右下对角线翻转
+				sx, sy = y, x
+			case 6: // 顺时针旋转 90 度
+				sx, sy = y, height-1-x
+			case 7: // 沿右上-左下对角线翻转
+				sx, sy = width-1-y, height-1-x
+			case 8: // 逆时针旋转 90 度
+				sx, sy = width-1-y, x
+			}
+			i, j := dst.PixOffset(x, y), src.PixOffset(sx, sy)
+			copy(dst.Pix[i:i+4], src.Pix[j:j+4])
+		}
+	}
+	return dst
+}
+
+// toRGBA 转换为从原点开始的 RGBA 图片，便于直接读写像素
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Rect.Min == (image.Point{}) {
+		return rgba
+	}
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Rect, img, bounds.Min, draw.Src)
+	return rgba
+}
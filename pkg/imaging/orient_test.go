@@ -0,0 +1,48 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrient(t *testing.T) {
+	// 3x2 图片，像素的 R 值依次为 0-5：
+	// 0 1 2
+	// 3 4 5
+	src := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	for i := 0; i < 6; i++ {
+		src.Set(i%3, i/3, color.RGBA{R: uint8(i), A: 255})
+	}
+	pixels := func(img image.Image) [][]uint8 {
+		bounds := img.Bounds()
+		rows := make([][]uint8, bounds.Dy())
+		for y := range rows {
+			for x := 0; x < bounds.Dx(); x++ {
+				r, _, _, _ := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+				rows[y] = append(rows[y], uint8(r>>8))
+			}
+		}
+		return rows
+	}
+
+	cases := map[int][][]uint8{
+		1: {{0, 1, 2}, {3, 4, 5}},
+		2: {{2, 1, 0}, {5, 4, 3}},
+		3: {{5, 4, 3}, {2, 1, 0}},
+		4: {{3, 4, 5}, {0, 1, 2}},
+		5: {{0, 3}, {1, 4}, {2, 5}},
+		6: {{3, 0}, {4, 1}, {5, 2}},
+		7: {{5, 2}, {4, 1}, {3, 0}},
+		8: {{2, 5}, {1, 4}, {0, 3}},
+	}
+	for orientation, expected := range cases {
+		assert.Equal(t, expected, pixels(Orient(src, orientation)), "orientation %d", orientation)
+	}
+
+	// 非零原点的子图
+	sub := src.SubImage(image.Rect(1, 0, 3, 2))
+	assert.Equal(t, [][]uint8{{4, 1}, {5, 2}}, pixels(Orient(sub, 6)))
+}
@@ -0,0 +1,153 @@
+package imaging
+
+import (
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/HugoSmits86/nativewebp"
+	"golang.org/x/image/draw"
+)
+
+// Format 输出图片格式
+type Format string
+
+const (
+	FormatJPEG Format = "jpeg"
+	FormatWebP Format = "webp"
+)
+
+// ParseFormat 解析格式名，空字符串视为 JPEG
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(name) {
+	case "", "jpg", "jpeg":
+		return FormatJPEG, nil
+	case "webp":
+		return FormatWebP, nil
+	}
+	return "", fmt.Errorf("不支持的图片格式：%s", name)
+}
+
+// Ext 格式对应的文件后缀
+func (f Format) Ext() string {
+	if f == FormatWebP {
+		return ".webp"
+	}
+	return ".jpg"
+}
+
+// ContentType 格式对应的 MIME 类型
+func (f Format) ContentType() string {
+	if f == FormatWebP {
+		return "image/webp"
+	}
+	return "image/jpeg"
+}
+
+// FitMode 缩放到指定宽高时的适配方式
+type FitMode string
+
+const (
+	FitContain FitMode = "contain" // 等比缩小到宽高之内
+	FitCover   FitMode = "cover"   // 等比缩放覆盖宽高并居中裁剪
+	FitFill    FitMode = "fill"    // 拉伸到指定宽高
+)
+
+// ParseFitMode 解析适配方式，空字符串视为 contain
+func ParseFitMode(name string) (FitMode, error) {
+	switch mode := FitMode(strings.ToLower(name)); mode {
+	case "":
+		return FitContain, nil
+	case FitContain, FitCover, FitFill:
+		return mode, nil
+	}
+	return "", fmt.Errorf("不支持的适配方式：%s", name)
+}
+
+// Decode 解码图片文件
+func Decode(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开文件失败：%w", err)
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("解码图片失败：%w", err)
+	}
+	return img, nil
+}
+
+// Fit 等比缩小图片使长边不超过 maxSize，图片本身更小时原样返回
+func Fit(img image.Image, maxSize int) image.Image {
+	return Resize(img, maxSize, maxSize, FitContain)
+}
+
+// Resize 按适配方式将图片缩放到 width x height，width 或 height 为 0 时只限制另一边。
+// contain 与 cover 不会放大图片，cover 在原图不足时裁剪出原图内最大的同比例区域
+func Resize(img image.Image, width, height int, mode FitMode) image.Image {
+	bounds := img.Bounds()
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+	if width <= 0 && height <= 0 {
+		return img
+	}
+	if width <= 0 || height <= 0 {
+		mode = FitContain
+	}
+
+	switch mode {
+	case FitFill:
+		return Scale(img, width, height)
+	case FitCover:
+		// 原图中与目标同比例的最大区域
+		cropWidth, cropHeight := srcWidth, max(1, srcWidth*height/width)
+		if cropHeight > srcHeight {
+			cropWidth, cropHeight = max(1, srcHeight*width/height), srcHeight
+		}
+		x := bounds.Min.X + (srcWidth-cropWidth)/2
+		y := bounds.Min.Y + (srcHeight-cropHeight)/2
+		crop := image.Rect(x, y, x+cropWidth, y+cropHeight)
+		return scaleRect(img, crop, min(width, cropWidth), min(height, cropHeight))
+	default:
+		if (width <= 0 || srcWidth <= width) && (height <= 0 || srcHeight <= height) {
+			return img
+		}
+		// 交叉相乘比较缩放比例，判断哪一边先达到限制
+		if height <= 0 || (width > 0 && width*srcHeight <= height*srcWidth) {
+			return Scale(img, width, max(1, srcHeight*width/srcWidth))
+		}
+		return Scale(img, max(1, srcWidth*height/srcHeight), height)
+	}
+}
+
+// Scale 将图片缩放到指定尺寸
+func Scale(img image.Image, width, height int) image.Image {
+	return scaleRect(img, img.Bounds(), width, height)
+}
+
+// scaleRect 将图片的 src 区域缩放到指定尺寸
+func scaleRect(img image.Image, src image.Rectangle, width, height int) image.Image {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	// 大幅缩小时先用近似双线性快速缩小，再用 Catmull-Rom 保证画质
+	if src.Dx() > width*4 && src.Dy() > height*4 {
+		mid := image.NewRGBA(image.Rect(0, 0, width*2, height*2))
+		draw.ApproxBiLinear.Scale(mid, mid.Bounds(), img, src, draw.Src, nil)
+		img, src = mid, mid.Bounds()
+	}
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, src, draw.Src, nil)
+	return dst
+}
+
+// Encode 按格式编码图片，quality 仅对 JPEG 有效（WebP 为无损编码）
+func Encode(w io.Writer, img image.Image, format Format, quality int) error {
+	switch format {
+	case FormatWebP:
+		return nativewebp.Encode(w, img, nil)
+	default:
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+	}
+}
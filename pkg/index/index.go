@@ -0,0 +1,211 @@
+// Package index 按目录持久化的媒体索引，记录文件信息与提取过的元数据，
+// 以路径 + 大小 + 修改时间判断缓存是否有效
+package index
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"media-app/pkg/exif"
+	"media-app/pkg/file"
+	"media-app/pkg/video"
+)
+
+// version 索引文件格式版本，格式或内容的计算方式变化时修改，旧索引自动失效
+const version = 4
+
+// Entry 单个文件的索引记录
+type Entry struct {
+	Name    string         `json:"name"`
+	Folder  string         `json:"folder"`
+	Type    file.MediaType `json:"type"`
+	Size    int64          `json:"size"`
+	ModTime time.Time      `json:"modTime"`
+
+	Probed  bool        `json:"probed,omitempty"`  // 是否已读取过尺寸与元数据
+	Width   int         `json:"width,omitempty"`   // 像素宽度
+	Height  int         `json:"height,omitempty"`  // 像素高度
+	Exif    *exif.Meta  `json:"exif,omitempty"`    // EXIF 元数据
+	Video   *video.Meta `json:"video,omitempty"`   // 视频元数据
+	HashSet bool        `json:"hashSet,omitempty"` // 是否已计算平均哈希
+	Hash    uint64      `json:"hash,omitempty"`    // 平均哈希
+}
+
+// valid 记录是否与文件当前的大小、修改时间一致
+func (e *Entry) valid(size int64, modTime time.Time) bool {
+	return e.Size == size && e.ModTime.Equal(modTime)
+}
+
+// indexFile 索引文件内容
+type indexFile struct {
+	Version int               `json:"version"`
+	Dir     string            `json:"dir"`
+	Entries map[string]*Entry `json:"entries"`
+}
+
+// Index 单个目录的索引，键为文件绝对路径，nil 时所有操作均为空操作
+type Index struct {
+	mux     sync.Mutex
+	dir     string
+	path    string
+	entries map[string]*Entry
+	dirty   bool
+}
+
+// Store 管理索引文件的存放目录，同一目录只打开一个 Index
+type Store struct {
+	mux     sync.Mutex
+	root    string
+	indexes map[string]*Index
+}
+
+// NewStore 创建索引存储，索引文件存放在 root 下
+func NewStore(root string) *Store {
+	return &Store{
+		root:    root,
+		indexes: make(map[string]*Index),
+	}
+}
+
+// Open 打开 dir 的索引，索引文件不存在或已损坏时返回空索引
+func (s *Store) Open(dir string) *Index {
+	if s == nil || dir == "" {
+		return nil
+	}
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if ix, ok := s.indexes[dir]; ok {
+		return ix
+	}
+	sum := sha1.Sum([]byte(dir))
+	ix := &Index{
+		dir:     dir,
+		path:    filepath.Join(s.root, hex.EncodeToString(sum[:8])+".json"),
+		entries: make(map[string]*Entry),
+	}
+	ix.load()
+	s.indexes[dir] = ix
+	return ix
+}
+
+// load 读取索引文件
+func (ix *Index) load() {
+	data, err := os.ReadFile(ix.path)
+	if err != nil {
+		return
+	}
+	var content indexFile
+	if err := json.Unmarshal(data, &content); err != nil {
+		return
+	}
+	if content.Version != version || content.Dir != ix.dir || content.Entries == nil {
+		return
+	}
+	ix.entries = content.Entries
+}
+
+// Len 返回索引中的记录数
+func (ix *Index) Len() int {
+	if ix == nil {
+		return 0
+	}
+	ix.mux.Lock()
+	defer ix.mux.Unlock()
+	return len(ix.entries)
+}
+
+// Entries 返回所有记录的副本
+func (ix *Index) Entries() map[string]Entry {
+	result := make(map[string]Entry)
+	if ix == nil {
+		return result
+	}
+	ix.mux.Lock()
+	defer ix.mux.Unlock()
+	for path, e := range ix.entries {
+		result[path] = *e
+	}
+	return result
+}
+
+// Get 返回 path 的记录，大小或修改时间不一致时视为失效
+func (ix *Index) Get(path string, size int64, modTime time.Time) (Entry, bool) {
+	if ix == nil {
+		return Entry{}, false
+	}
+	ix.mux.Lock()
+	defer ix.mux.Unlock()
+	e, ok := ix.entries[path]
+	if !ok || !e.valid(size, modTime) {
+		return Entry{}, false
+	}
+	return *e, true
+}
+
+// Update 修改 path 的记录，文件已变化时先清空旧的元数据
+func (ix *Index) Update(path string, size int64, modTime time.Time, fn func(e *Entry)) {
+	if ix == nil {
+		return
+	}
+	ix.mux.Lock()
+	defer ix.mux.Unlock()
+	e, ok := ix.entries[path]
+	if !ok || !e.valid(size, modTime) {
+		e = &Entry{Size: size, ModTime: modTime}
+		ix.entries[path] = e
+	}
+	fn(e)
+	ix.dirty = true
+}
+
+// Prune 删除 keep 返回 false 的记录
+func (ix *Index) Prune(keep func(path string, e Entry) bool) {
+	if ix == nil {
+		return
+	}
+	ix.mux.Lock()
+	defer ix.mux.Unlock()
+	for path, e := range ix.entries {
+		if !keep(path, *e) {
+			delete(ix.entries, path)
+			ix.dirty = true
+		}
+	}
+}
+
+// Save 将有变化的索引写入磁盘
+func (ix *Index) Save() error {
+	if ix == nil {
+		return nil
+	}
+	ix.mux.Lock()
+	defer ix.mux.Unlock()
+	if !ix.dirty {
+		return nil
+	}
+
+	data, err := json.Marshal(indexFile{Version: version, Dir: ix.dir, Entries: ix.entries})
+	if err != nil {
+		return fmt.Errorf("序列化索引失败：%w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(ix.path), 0755); err != nil {
+		return fmt.Errorf("创建索引目录失败：%w", err)
+	}
+	// 先写临时文件再替换，避免写入中断导致索引损坏
+	tmp := ix.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("写入索引失败：%w", err)
+	}
+	if err := os.Rename(tmp, ix.path); err != nil {
+		return fmt.Errorf("替换索引失败：%w", err)
+	}
+	ix.dirty = false
+	return nil
+}
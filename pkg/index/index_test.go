@@ -0,0 +1,52 @@
+package index
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIndex(t *testing.T) {
+	root := t.TempDir()
+	modTime := time.Now().Truncate(time.Second)
+
+	ix := NewStore(root).Open("/photos")
+	ix.Update("/photos/a.jpg", 10, modTime, func(e *Entry) {
+		e.Name = "a.jpg"
+		e.Probed = true
+		e.Width, e.Height = 40, 30
+	})
+	ix.Update("/photos/b.jpg", 20, modTime, func(e *Entry) {
+		e.HashSet, e.Hash = true, 42
+	})
+	assert.Nil(t, ix.Save())
+
+	// 重新打开后从磁盘读取
+	ix = NewStore(root).Open("/photos")
+	assert.Equal(t, 2, ix.Len())
+	entry, ok := ix.Get("/photos/a.jpg", 10, modTime)
+	assert.True(t, ok)
+	assert.Equal(t, 40, entry.Width)
+
+	// 大小或修改时间变化后失效
+	_, ok = ix.Get("/photos/a.jpg", 11, modTime)
+	assert.False(t, ok)
+	_, ok = ix.Get("/photos/b.jpg", 20, modTime.Add(time.Second))
+	assert.False(t, ok)
+
+	// 文件变化后更新会丢弃旧的元数据
+	ix.Update("/photos/a.jpg", 11, modTime, func(e *Entry) {})
+	entry, _ = ix.Get("/photos/a.jpg", 11, modTime)
+	assert.False(t, entry.Probed)
+
+	ix.Prune(func(path string, e Entry) bool { return path != "/photos/b.jpg" })
+	assert.Equal(t, 1, ix.Len())
+
+	// 其他目录的索引互不影响
+	assert.Equal(t, 0, NewStore(root).Open("/videos").Len())
+
+	var nilStore *Store
+	assert.Nil(t, nilStore.Open("/photos"))
+	assert.Nil(t, nilStore.Open("/photos").Save())
+}
@@ -0,0 +1,109 @@
+package thumb
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"media-app/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// diskCacheSize 磁盘缓存的默认容量（字节）
+const diskCacheSize = 512 << 20
+
+// touchInterval 命中缓存时更新文件修改时间的最小间隔，修改时间作为最近访问时间用于淘汰
+const touchInterval = time.Hour
+
+// cacheFile 磁盘缓存文件
+type cacheFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// SetDiskLimit 设置磁盘缓存的容量（字节），超出后淘汰最久未访问的缓存文件
+func (s *Service) SetDiskLimit(limit int64) {
+	s.mux.Lock()
+	s.diskLimit = limit
+	s.mux.Unlock()
+	s.trackDisk(0)
+}
+
+// touch 命中缓存时更新修改时间，标记为最近访问
+func touch(path string, info os.FileInfo) {
+	if now := time.Now(); now.Sub(info.ModTime()) > touchInterval {
+		_ = os.Chtimes(path, now, now)
+	}
+}
+
+// trackDisk 记录新写入的缓存大小，首次写入或超出容量时在后台统计并淘汰
+func (s *Service) trackDisk(size int64) {
+	s.mux.Lock()
+	s.diskSize += size
+	start := !s.evicting && (!s.diskScanned || s.diskSize > s.diskLimit)
+	if start {
+		s.evicting = true
+	}
+	s.mux.Unlock()
+	if start {
+		go s.evict()
+	}
+}
+
+// evict 统计磁盘缓存大小，超出容量时按修改时间从早到晚删除，直到降到容量的 90%。
+// 统计期间新写入的文件另外累计，仍超出容量时再次清理
+func (s *Service) evict() {
+	s.mux.Lock()
+	s.diskSize = 0
+	s.mux.Unlock()
+
+	var files []cacheFile
+	var total int64
+	_ = filepath.WalkDir(s.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		files = append(files, cacheFile{path: path, size: info.Size(), modTime: info.ModTime()})
+		total += info.Size()
+		return nil
+	})
+
+	s.mux.Lock()
+	limit := s.diskLimit
+	s.mux.Unlock()
+	if total > limit {
+		sort.Slice(files, func(i, j int) bool {
+			return files[i].modTime.Before(files[j].modTime)
+		})
+		before, removed := total, 0
+		for _, f := range files {
+			if total <= limit/10*9 {
+				break
+			}
+			if err := os.Remove(f.path); err == nil {
+				total -= f.size
+				removed++
+			}
+		}
+		logger.Info("已清理缩略图缓存", zap.Int("removed", removed), zap.Int64("before", before), zap.Int64("after", total))
+	}
+
+	s.mux.Lock()
+	added := s.diskSize
+	s.diskSize += total
+	s.diskScanned = true
+	again := added > 0 && s.diskSize > s.diskLimit
+	s.evicting = again
+	s.mux.Unlock()
+	if again {
+		go s.evict()
+	}
+}
@@ -0,0 +1,68 @@
+package thumb
+
+import (
+	"container/list"
+	"sync"
+)
+
+// memoryCache 按总字节数限制容量的 LRU 缓存，存放编码后的图片
+type memoryCache struct {
+	mux      sync.Mutex
+	capacity int64
+	size     int64
+	items    map[string]*list.Element
+	order    *list.List // 最近使用的在前
+}
+
+// memoryEntry 缓存项
+type memoryEntry struct {
+	key  string
+	data []byte
+}
+
+// newMemoryCache 创建容量为 capacity 字节的缓存
+func newMemoryCache(capacity int64) *memoryCache {
+	return &memoryCache{
+		capacity: capacity,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// get 读取缓存并标记为最近使用
+func (c *memoryCache) get(key string) ([]byte, bool) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	elem, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*memoryEntry).data, true
+}
+
+// add 写入缓存，超出容量时淘汰最久未使用的项，超过容量的单项不缓存
+func (c *memoryCache) add(key string, data []byte) {
+	size := int64(len(data))
+	if size > c.capacity {
+		return
+	}
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if elem, ok := c.items[key]; ok {
+		c.size -= int64(len(elem.Value.(*memoryEntry).data))
+		elem.Value.(*memoryEntry).data = data
+		c.size += size
+		c.order.MoveToFront(elem)
+	} else {
+		c.items[key] = c.order.PushFront(&memoryEntry{key: key, data: data})
+		c.size += size
+	}
+	for c.size > c.capacity {
+		oldest := c.order.Back()
+		entry := oldest.Value.(*memoryEntry)
+		c.order.Remove(oldest)
+		delete(c.items, entry.key)
+		c.size -= int64(len(entry.data))
+	}
+}
@@ -0,0 +1,304 @@
+// Package thumb 缩略图服务，按需生成缩小后的预览图并缓存在磁盘，
+// 任意尺寸的缩放结果另有内存 LRU 缓存。
+// 缓存以原图路径、大小与修改时间为键，原图变化后自动重新生成；
+// 磁盘缓存超出容量时淘汰最久未访问的文件
+package thumb
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sync"
+	"time"
+
+	"media-app/pkg/imaging"
+	"media-app/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// Sizes 支持的缩略图长边尺寸
+var Sizes = []int{160, 320, 640}
+
+// DefaultSize 列表默认使用的缩略图尺寸
+const DefaultSize = 320
+
+// URLPrefix 缩略图的 HTTP 路径前缀，完整路径为 /_thumb/<尺寸>/r/<根目录 ID>/<相对路径>
+const URLPrefix = "/_thumb/"
+
+// quality JPEG 缩略图默认质量
+const quality = 82
+
+// MaxDimension 缩放输出允许的最大边长
+const MaxDimension = 4096
+
+// SizeBuckets 缩放尺寸的档位，请求的尺寸向上取整到档位，相近的尺寸共用缓存，避免每种尺寸都生成缓存文件
+var SizeBuckets = []int{64, 128, 192, 256, 320, 384, 512, 640, 768, 1024, 1280, 1536, 2048, 3072, MaxDimension}
+
+// qualityLevels JPEG 质量的档位，请求的质量取最接近的档位
+var qualityLevels = []int{50, 70, quality, 95}
+
+// cacheVersion 生成方式变化时修改，使旧的缓存文件失效
+const cacheVersion = 2
+
+// memoryCacheSize 内存缓存的容量（字节）
+const memoryCacheSize = 64 << 20
+
+// Options 缩放参数
+type Options struct {
+	Width   int             // 目标宽度，0 表示只限制高度；向上取整到 SizeBuckets 的档位
+	Height  int             // 目标高度，0 表示只限制宽度；向上取整到 SizeBuckets 的档位
+	Fit     imaging.FitMode // 适配方式，默认 contain
+	Format  imaging.Format  // 输出格式，默认 JPEG
+	Quality int             // JPEG 质量 1-100，取最接近的档位，0 使用默认值；其他格式忽略
+}
+
+// normalize 校验参数并填充默认值
+func (o Options) normalize() (Options, error) {
+	if o.Width < 0 || o.Height < 0 || o.Width > MaxDimension || o.Height > MaxDimension {
+		return o, fmt.Errorf("尺寸超出范围：%dx%d", o.Width, o.Height)
+	}
+	if o.Width == 0 && o.Height == 0 {
+		return o, fmt.Errorf("需要指定宽度或高度")
+	}
+	if o.Quality < 0 || o.Quality > 100 {
+		return o, fmt.Errorf("质量超出范围：%d", o.Quality)
+	}
+	if o.Fit == "" {
+		o.Fit = imaging.FitContain
+	}
+	if o.Format == "" {
+		o.Format = imaging.FormatJPEG
+	}
+	// 质量只对 JPEG 有效，其他格式不同的质量参数共用缓存
+	switch {
+	case o.Format != imaging.FormatJPEG:
+		o.Quality = 0
+	case o.Quality == 0:
+		o.Quality = quality
+	default:
+		o.Quality = roundQuality(o.Quality)
+	}
+	o.Width, o.Height = roundSize(o.Width), roundSize(o.Height)
+	return o, nil
+}
+
+// roundSize 将尺寸向上取整到 SizeBuckets 的档位，0 保持不变
+func roundSize(size int) int {
+	if size == 0 {
+		return 0
+	}
+	for _, bucket := range SizeBuckets {
+		if size <= bucket {
+			return bucket
+		}
+	}
+	return MaxDimension
+}
+
+// roundQuality 取最接近的质量档位，距离相同时取较高的质量
+func roundQuality(q int) int {
+	result := qualityLevels[0]
+	for _, level := range qualityLevels {
+		if abs(level-q) <= abs(result-q) {
+			result = level
+		}
+	}
+	return result
+}
+
+// abs 返回整数的绝对值
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// Variant 缩放后的图片
+type Variant struct {
+	Data    []byte
+	Format  imaging.Format
+	ModTime time.Time // 原图的修改时间
+	Key     string    // 缓存键，原图或参数变化时改变
+}
+
+// Service 缩略图服务
+type Service struct {
+	dir    string
+	jobs   chan struct{} // 限制同时生成的数量
+	memory *memoryCache
+
+	mux         sync.Mutex
+	inflight    map[string]*call
+	diskLimit   int64 // 磁盘缓存的容量
+	diskSize    int64 // 磁盘缓存的大小，为统计值
+	diskScanned bool  // 是否已统计过磁盘缓存
+	evicting    bool  // 是否正在统计或清理磁盘缓存
+}
+
+// call 正在生成的缩略图，同一缩略图的并发请求共享结果
+type call struct {
+	done chan struct{}
+	err  error
+}
+
+// NewService 创建缩略图服务，缓存存放在 dir 下，workers 为同时生成的最大数量，<=0 时使用 CPU 数
+func NewService(dir string, workers int) *Service {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	return &Service{
+		dir:       dir,
+		jobs:      make(chan struct{}, workers),
+		memory:    newMemoryCache(memoryCacheSize),
+		inflight:  make(map[string]*call),
+		diskLimit: diskCacheSize,
+	}
+}
+
+// ValidSize 尺寸是否受支持
+func ValidSize(size int) bool {
+	return slices.Contains(Sizes, size)
+}
+
+// Get 返回 path 的缩略图文件路径，缓存不存在时生成
+func (s *Service) Get(path string, size int, format imaging.Format) (string, error) {
+	if !ValidSize(size) {
+		return "", fmt.Errorf("不支持的缩略图尺寸：%d", size)
+	}
+	info, err := stat(path)
+	if err != nil {
+		return "", err
+	}
+	opts := Options{Width: size, Height: size, Fit: imaging.FitContain, Format: format, Quality: quality}
+	return s.render(path, cacheKey(path, info, opts), opts)
+}
+
+// Resize 返回按 opts 缩放后的 path，依次查找内存缓存、磁盘缓存，都不存在时生成
+func (s *Service) Resize(path string, opts Options) (*Variant, error) {
+	opts, err := opts.normalize()
+	if err != nil {
+		return nil, err
+	}
+	info, err := stat(path)
+	if err != nil {
+		return nil, err
+	}
+	key := cacheKey(path, info, opts)
+	variant := &Variant{Format: opts.Format, ModTime: info.ModTime(), Key: key}
+	if data, ok := s.memory.get(key); ok {
+		variant.Data = data
+		return variant, nil
+	}
+
+	target, err := s.render(path, key, opts)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(target)
+	if err != nil {
+		return nil, fmt.Errorf("读取缩放结果失败：%w", err)
+	}
+	s.memory.add(key, data)
+	variant.Data = data
+	return variant, nil
+}
+
+// stat 读取原图信息，原图必须是文件
+func stat(path string) (os.FileInfo, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取原图失败：%w", err)
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("指定路径不是文件：%s", path)
+	}
+	return info, nil
+}
+
+// render 返回缓存文件路径，不存在时生成，同一缓存键的并发请求只生成一次
+func (s *Service) render(path, key string, opts Options) (string, error) {
+	target := filepath.Join(s.dir, key[:2], key+opts.Format.Ext())
+	if info, err := os.Stat(target); err == nil {
+		touch(target, info)
+		return target, nil
+	}
+
+	s.mux.Lock()
+	if c, ok := s.inflight[key]; ok {
+		s.mux.Unlock()
+		<-c.done
+		return target, c.err
+	}
+	c := &call{done: make(chan struct{})}
+	s.inflight[key] = c
+	s.mux.Unlock()
+
+	s.jobs <- struct{}{}
+	c.err = s.generate(path, target, opts)
+	<-s.jobs
+
+	s.mux.Lock()
+	delete(s.inflight, key)
+	s.mux.Unlock()
+	close(c.done)
+
+	if c.err != nil {
+		return "", c.err
+	}
+	if info, err := os.Stat(target); err == nil {
+		s.trackDisk(info.Size())
+	}
+	return target, nil
+}
+
+// generate 解码原图、缩放并写入缓存文件
+func (s *Service) generate(path, target string, opts Options) error {
+	img, err := imaging.Decode(path)
+	if err != nil {
+		return err
+	}
+	// 先按存储方向缩放再旋转，需要处理的像素更少；旋转 90 度时目标宽高互换
+	orientation := imaging.Orientation(path)
+	width, height := opts.Width, opts.Height
+	if imaging.SwapsAxes(orientation) {
+		width, height = height, width
+	}
+	img = imaging.Orient(imaging.Resize(img, width, height, opts.Fit), orientation)
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("创建缩略图目录失败：%w", err)
+	}
+	// 先写临时文件再替换，避免并发读取到不完整的缩略图
+	tmp, err := os.CreateTemp(filepath.Dir(target), "thumb-*")
+	if err != nil {
+		return fmt.Errorf("创建缩略图失败：%w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := imaging.Encode(tmp, img, opts.Format, opts.Quality); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("编码缩略图失败：%w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("写入缩略图失败：%w", err)
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return fmt.Errorf("保存缩略图失败：%w", err)
+	}
+	logger.Debug("已生成缩略图", zap.String("path", path), zap.Int("width", opts.Width), zap.Int("height", opts.Height),
+		zap.String("fit", string(opts.Fit)), zap.String("format", string(opts.Format)))
+	return nil
+}
+
+// cacheKey 由原图路径、大小、修改时间与缩放参数计算缓存键
+func cacheKey(path string, info os.FileInfo, opts Options) string {
+	sum := sha1.Sum([]byte(fmt.Sprintf("%d|%s|%d|%d|%dx%d|%s|%s|%d", cacheVersion, path, info.Size(), info.ModTime().UnixNano(),
+		opts.Width, opts.Height, opts.Fit, opts.Format, opts.Quality)))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,281 @@
+package thumb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"media-app/pkg/imaging"
+	"media-app/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	cfg := logger.DefaultConfig()
+	cfg.FileName = filepath.Join(os.TempDir(), "media-app-test", "app.log")
+	cfg.OutputConsole = false
+	if err := logger.Init(cfg); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// writePNG 生成指定尺寸的测试图片
+func writePNG(t *testing.T, path string, width, height int) {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		img.Set(x, height/2, color.RGBA{R: 255, A: 255})
+	}
+	f, err := os.Create(path)
+	assert.Nil(t, err)
+	defer f.Close()
+	assert.Nil(t, png.Encode(f, img))
+}
+
+func TestService(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "wide.png")
+	writePNG(t, src, 1000, 500)
+	s := NewService(t.TempDir(), 2)
+
+	// 并发请求同一缩略图只生成一次
+	var wg sync.WaitGroup
+	paths := make([]string, 4)
+	for i := range paths {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			path, err := s.Get(src, 320, imaging.FormatJPEG)
+			assert.Nil(t, err)
+			paths[i] = path
+		}()
+	}
+	wg.Wait()
+	for _, path := range paths {
+		assert.Equal(t, paths[0], path)
+	}
+	width, height, err := imaging.Size(paths[0])
+	assert.Nil(t, err)
+	assert.Equal(t, []int{320, 160}, []int{width, height})
+
+	webp, err := s.Get(src, 160, imaging.FormatWebP)
+	assert.Nil(t, err)
+	assert.Equal(t, ".webp", filepath.Ext(webp))
+	width, height, err = imaging.Size(webp)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{160, 80}, []int{width, height})
+
+	// 原图变化后使用新的缓存文件
+	writePNG(t, src, 200, 400)
+	assert.Nil(t, os.Chtimes(src, time.Now(), time.Now().Add(time.Minute)))
+	path, err := s.Get(src, 320, imaging.FormatJPEG)
+	assert.Nil(t, err)
+	assert.NotEqual(t, paths[0], path)
+	width, height, err = imaging.Size(path)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{160, 320}, []int{width, height})
+
+	_, err = s.Get(src, 100, imaging.FormatJPEG)
+	assert.NotNil(t, err)
+}
+
+func TestResize(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "wide.png")
+	writePNG(t, src, 1000, 500)
+	dir := t.TempDir()
+	s := NewService(dir, 2)
+
+	size := func(v *Variant) []int {
+		img, _, err := image.Decode(bytes.NewReader(v.Data))
+		assert.Nil(t, err)
+		return []int{img.Bounds().Dx(), img.Bounds().Dy()}
+	}
+
+	cover, err := s.Resize(src, Options{Width: 320, Height: 320, Fit: imaging.FitCover})
+	assert.Nil(t, err)
+	assert.Equal(t, []int{320, 320}, size(cover))
+
+	contain, err := s.Resize(src, Options{Width: 512, Height: 320})
+	assert.Nil(t, err)
+	assert.Equal(t, []int{512, 256}, size(contain))
+	assert.NotEqual(t, cover.Key, contain.Key)
+
+	// 尺寸向上取整到档位，相近的尺寸共用缓存
+	rounded, err := s.Resize(src, Options{Width: 300, Height: 290, Fit: imaging.FitCover})
+	assert.Nil(t, err)
+	assert.Equal(t, cover.Key, rounded.Key)
+
+	height, err := s.Resize(src, Options{Height: 128, Format: imaging.FormatWebP})
+	assert.Nil(t, err)
+	assert.Equal(t, []int{256, 128}, size(height))
+	assert.Equal(t, imaging.FormatWebP, height.Format)
+
+	// WebP 忽略质量参数
+	quality, err := s.Resize(src, Options{Height: 128, Format: imaging.FormatWebP, Quality: 50})
+	assert.Nil(t, err)
+	assert.Equal(t, height.Key, quality.Key)
+
+	// JPEG 质量取最接近的档位
+	q80, err := s.Resize(src, Options{Width: 320, Height: 320, Fit: imaging.FitCover, Quality: 80})
+	assert.Nil(t, err)
+	assert.Equal(t, cover.Key, q80.Key)
+
+	fill, err := s.Resize(src, Options{Width: 128, Height: 128, Fit: imaging.FitFill, Quality: 50})
+	assert.Nil(t, err)
+	assert.Equal(t, []int{128, 128}, size(fill))
+
+	// 不放大图片，cover 裁剪出原图内最大的同比例区域
+	large, err := s.Resize(src, Options{Width: 2000, Height: 2000, Fit: imaging.FitCover})
+	assert.Nil(t, err)
+	assert.Equal(t, []int{500, 500}, size(large))
+
+	// 磁盘缓存被清空后仍可从内存缓存读取
+	assert.Nil(t, os.RemoveAll(dir))
+	cached, err := s.Resize(src, Options{Width: 320, Height: 320, Fit: imaging.FitCover})
+	assert.Nil(t, err)
+	assert.Equal(t, cover.Data, cached.Data)
+
+	_, err = s.Resize(src, Options{})
+	assert.NotNil(t, err)
+	_, err = s.Resize(src, Options{Width: MaxDimension + 1})
+	assert.NotNil(t, err)
+	_, err = s.Resize(src, Options{Width: 100, Quality: 101})
+	assert.NotNil(t, err)
+}
+
+func TestMemoryCache(t *testing.T) {
+	c := newMemoryCache(10)
+	c.add("a", []byte("1234"))
+	c.add("b", []byte("1234"))
+	_, ok := c.get("a")
+	assert.True(t, ok)
+
+	// 超出容量时淘汰最久未使用的 b
+	c.add("c", []byte("1234"))
+	_, ok = c.get("b")
+	assert.False(t, ok)
+	_, ok = c.get("a")
+	assert.True(t, ok)
+
+	c.add("big", make([]byte, 11))
+	_, ok = c.get("big")
+	assert.False(t, ok)
+	assert.Equal(t, int64(8), c.size)
+}
+
+// writeOrientedJPEG 生成带 EXIF 方向标记的 JPEG
+func writeOrientedJPEG(t *testing.T, path string, width, height int, orientation uint16) {
+	t.Helper()
+	var buf bytes.Buffer
+	assert.Nil(t, jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height)), nil))
+
+	// 小端 TIFF，IFD0 只有一个方向字段
+	tiff := []byte{'I', 'I', 42, 0, 8, 0, 0, 0, 1, 0}
+	tiff = binary.LittleEndian.AppendUint16(tiff, 0x0112)
+	tiff = binary.LittleEndian.AppendUint16(tiff, 3)
+	tiff = binary.LittleEndian.AppendUint32(tiff, 1)
+	tiff = binary.LittleEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+	app1 := append([]byte("Exif\x00\x00"), tiff...)
+	segment := append([]byte{0xFF, 0xE1}, binary.BigEndian.AppendUint16(nil, uint16(len(app1)+2))...)
+
+	data := buf.Bytes()
+	out := append(append(append([]byte{}, data[:2]...), segment...), app1...)
+	assert.Nil(t, os.WriteFile(path, append(out, data[2:]...), 0644))
+}
+
+func TestResizeOrientation(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "portrait.jpg")
+	writeOrientedJPEG(t, src, 800, 400, 6)
+	s := NewService(t.TempDir(), 1)
+
+	// 存储为横图，旋转 90 度后为竖图
+	path, err := s.Get(src, 320, imaging.FormatJPEG)
+	assert.Nil(t, err)
+	width, height, err := imaging.Size(path)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{160, 320}, []int{width, height})
+
+	variant, err := s.Resize(src, Options{Width: 128, Height: 192, Fit: imaging.FitCover})
+	assert.Nil(t, err)
+	img, _, err := image.Decode(bytes.NewReader(variant.Data))
+	assert.Nil(t, err)
+	assert.Equal(t, image.Rect(0, 0, 128, 192), img.Bounds())
+}
+
+func TestRoundOptions(t *testing.T) {
+	assert.Equal(t, 0, roundSize(0))
+	assert.Equal(t, 64, roundSize(1))
+	assert.Equal(t, 512, roundSize(400))
+	assert.Equal(t, MaxDimension, roundSize(3500))
+	assert.Equal(t, 50, roundQuality(1))
+	assert.Equal(t, 70, roundQuality(65))
+	assert.Equal(t, quality, roundQuality(80))
+	assert.Equal(t, 95, roundQuality(100))
+}
+
+// cacheFiles 返回磁盘缓存中的文件
+func cacheFiles(t *testing.T, dir string) map[string]int64 {
+	t.Helper()
+	files := map[string]int64{}
+	assert.Nil(t, filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			info, err := d.Info()
+			assert.Nil(t, err)
+			files[path] = info.Size()
+		}
+		return err
+	}))
+	return files
+}
+
+func TestDiskLimit(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "wide.png")
+	writePNG(t, src, 1000, 500)
+	dir := t.TempDir()
+	s := NewService(dir, 1)
+
+	// 生成几个缩略图，最早生成的一个标记为很久未访问
+	var paths []string
+	for _, size := range Sizes {
+		path, err := s.Get(src, size, imaging.FormatJPEG)
+		assert.Nil(t, err)
+		paths = append(paths, path)
+	}
+	old := time.Now().Add(-2 * time.Hour)
+	for _, path := range paths {
+		assert.Nil(t, os.Chtimes(path, old, old))
+		old = old.Add(time.Minute)
+	}
+
+	// 命中缓存时更新访问时间，不会被优先淘汰
+	_, err := s.Get(src, Sizes[0], imaging.FormatJPEG)
+	assert.Nil(t, err)
+	info, err := os.Stat(paths[0])
+	assert.Nil(t, err)
+	assert.WithinDuration(t, time.Now(), info.ModTime(), time.Minute)
+
+	// 超出容量时淘汰最久未访问的文件，直到不超过容量
+	files := cacheFiles(t, dir)
+	limit := files[paths[0]] + files[paths[2]]
+	s.SetDiskLimit(limit)
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(paths[1])
+		return os.IsNotExist(err)
+	}, 5*time.Second, 20*time.Millisecond)
+	assert.FileExists(t, paths[0])
+	var total int64
+	for _, size := range cacheFiles(t, dir) {
+		total += size
+	}
+	assert.LessOrEqual(t, total, limit)
+}
@@ -0,0 +1,215 @@
+package video
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+// bmffEpoch ISO BMFF 时间的起点
+var bmffEpoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// isBMFF 文件开头的 box 类型是否属于 ISO BMFF
+func isBMFF(typ []byte) bool {
+	switch string(typ) {
+	case "ftyp", "moov", "mdat", "wide", "free", "skip":
+		return true
+	}
+	return false
+}
+
+// box ISO BMFF 的一个 box
+type box struct {
+	typ  string
+	data []byte // 不含头部的内容
+}
+
+// boxes 解析 data 中连续的子 box
+func boxes(data []byte) []box {
+	var result []box
+	for len(data) >= 8 {
+		size := uint64(binary.BigEndian.Uint32(data[:4]))
+		typ := string(data[4:8])
+		header := uint64(8)
+		switch size {
+		case 0:
+			size = uint64(len(data))
+		case 1:
+			if len(data) < 16 {
+				return result
+			}
+			size = binary.BigEndian.Uint64(data[8:16])
+			header = 16
+		}
+		if size < header || size > uint64(len(data)) {
+			return result
+		}
+		result = append(result, box{typ: typ, data: data[header:size]})
+		data = data[size:]
+	}
+	return result
+}
+
+// child 返回第一个指定类型的子 box
+func child(data []byte, path ...string) []byte {
+	for _, typ := range path {
+		found := false
+		for _, b := range boxes(data) {
+			if b.typ == typ {
+				data, found = b.data, true
+				break
+			}
+		}
+		if !found {
+			return nil
+		}
+	}
+	return data
+}
+
+// decodeBMFF 在顶层 box 中找到 moov 并解析
+func decodeBMFF(r io.ReaderAt, size int64) (*Meta, error) {
+	header := make([]byte, 16)
+	for offset := int64(0); offset+8 <= size; {
+		if _, err := r.ReadAt(header[:8], offset); err != nil {
+			return nil, fmt.Errorf("读取 box 失败：%w", err)
+		}
+		boxSize := int64(binary.BigEndian.Uint32(header[:4]))
+		typ := string(header[4:8])
+		headerSize := int64(8)
+		switch boxSize {
+		case 0:
+			boxSize = size - offset
+		case 1:
+			if _, err := r.ReadAt(header[8:16], offset+8); err != nil {
+				return nil, fmt.Errorf("读取 box 失败：%w", err)
+			}
+			boxSize = int64(binary.BigEndian.Uint64(header[8:16]))
+			headerSize = 16
+		}
+		if boxSize < headerSize {
+			return nil, ErrUnsupported
+		}
+
+		if typ == "moov" {
+			if boxSize-headerSize > maxHeaderSize {
+				return nil, fmt.Errorf("moov 过大：%d", boxSize)
+			}
+			data := make([]byte, boxSize-headerSize)
+			if _, err := r.ReadAt(data, offset+headerSize); err != nil {
+				return nil, fmt.Errorf("读取 moov 失败：%w", err)
+			}
+			return parseMoov(data), nil
+		}
+		offset += boxSize
+	}
+	return nil, ErrUnsupported
+}
+
+// parseMoov 解析 moov 中的时长、创建时间与第一条视频轨道
+func parseMoov(moov []byte) *Meta {
+	meta := &Meta{}
+	if mvhd := child(moov, "mvhd"); mvhd != nil {
+		created, timescale, duration := parseTimes(mvhd)
+		meta.CreationTime = created
+		if timescale > 0 {
+			meta.Duration = float64(duration) / float64(timescale)
+		}
+	}
+
+	for _, trak := range boxes(moov) {
+		if trak.typ != "trak" || string(handlerType(trak.data)) != "vide" {
+			continue
+		}
+		if tkhd := child(trak.data, "tkhd"); tkhd != nil {
+			meta.Width, meta.Height = trackSize(tkhd)
+		}
+		stbl := child(trak.data, "mdia", "minf", "stbl")
+		if stsd := child(stbl, "stsd"); len(stsd) >= 16 {
+			// 第一个样本描述：size(4) + 编码格式(4)
+			entry := stsd[8:]
+			meta.Codec = string(entry[4:8])
+			// 视觉样本描述中宽高位于偏移 32 处，轨道头缺失宽高时使用
+			if meta.Width == 0 && len(entry) >= 36 {
+				meta.Width = int(binary.BigEndian.Uint16(entry[32:34]))
+				meta.Height = int(binary.BigEndian.Uint16(entry[34:36]))
+			}
+		}
+		if mdhd := child(trak.data, "mdia", "mdhd"); mdhd != nil {
+			_, timescale, duration := parseTimes(mdhd)
+			if frames := sampleCount(child(stbl, "stts")); frames > 0 && duration > 0 && timescale > 0 {
+				meta.FrameRate = float64(frames) * float64(timescale) / float64(duration)
+			}
+		}
+		break
+	}
+	return meta
+}
+
+// parseTimes 解析 mvhd/mdhd 中的创建时间、时间刻度与时长
+func parseTimes(data []byte) (time.Time, uint32, uint64) {
+	if len(data) < 4 {
+		return time.Time{}, 0, 0
+	}
+	var created uint64
+	var timescale uint32
+	var duration uint64
+	if data[0] == 1 {
+		if len(data) < 32 {
+			return time.Time{}, 0, 0
+		}
+		created = binary.BigEndian.Uint64(data[4:12])
+		timescale = binary.BigEndian.Uint32(data[20:24])
+		duration = binary.BigEndian.Uint64(data[24:32])
+	} else {
+		if len(data) < 20 {
+			return time.Time{}, 0, 0
+		}
+		created = uint64(binary.BigEndian.Uint32(data[4:8]))
+		timescale = binary.BigEndian.Uint32(data[12:16])
+		duration = uint64(binary.BigEndian.Uint32(data[16:20]))
+	}
+
+	var creationTime time.Time
+	if created > 0 {
+		creationTime = bmffEpoch.Add(time.Duration(created) * time.Second)
+	}
+	return creationTime, timescale, duration
+}
+
+// handlerType 返回轨道的 handler 类型，如 vide、soun
+func handlerType(trak []byte) []byte {
+	hdlr := child(trak, "mdia", "hdlr")
+	if len(hdlr) < 12 {
+		return nil
+	}
+	return hdlr[8:12]
+}
+
+// trackSize 解析 tkhd 中 16.16 定点数表示的宽高
+func trackSize(tkhd []byte) (int, int) {
+	offset := 76
+	if len(tkhd) > 0 && tkhd[0] == 1 {
+		offset = 88
+	}
+	if len(tkhd) < offset+8 {
+		return 0, 0
+	}
+	width := binary.BigEndian.Uint32(tkhd[offset:]) >> 16
+	height := binary.BigEndian.Uint32(tkhd[offset+4:]) >> 16
+	return int(width), int(height)
+}
+
+// sampleCount 统计 stts 中的样本总数
+func sampleCount(stts []byte) uint64 {
+	if len(stts) < 8 {
+		return 0
+	}
+	count := int(binary.BigEndian.Uint32(stts[4:8]))
+	var total uint64
+	for i := 0; i < count && 8+i*8+8 <= len(stts); i++ {
+		total += uint64(binary.BigEndian.Uint32(stts[8+i*8:]))
+	}
+	return total
+}
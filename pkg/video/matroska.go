@@ -0,0 +1,262 @@
+package video
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+)
+
+// ebmlMagic EBML 头的元素 ID
+var ebmlMagic = []byte{0x1A, 0x45, 0xDF, 0xA3}
+
+// matroskaEpoch Matroska DateUTC 的起点
+var matroskaEpoch = time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Matroska 元素 ID
+const (
+	idEBML            = 0x1A45DFA3
+	idSegment         = 0x18538067
+	idInfo            = 0x1549A966
+	idTimecodeScale   = 0x2AD7B1
+	idDuration        = 0x4489
+	idDateUTC         = 0x4461
+	idTracks          = 0x1654AE6B
+	idTrackEntry      = 0xAE
+	idTrackType       = 0x83
+	idCodecID         = 0x86
+	idDefaultDuration = 0x23E383
+	idVideo           = 0xE0
+	idPixelWidth      = 0xB0
+	idPixelHeight     = 0xBA
+	idCluster         = 0x1F43B675
+)
+
+// trackTypeVideo 视频轨道的 TrackType
+const trackTypeVideo = 1
+
+// unknownSize 长度未知的元素
+const unknownSize = math.MaxUint64
+
+// matroskaCodecs Matroska CodecID -> FourCC
+var matroskaCodecs = map[string]string{
+	"V_MPEG4/ISO/AVC":  "avc1",
+	"V_MPEGH/ISO/HEVC": "hvc1",
+	"V_VP8":            "VP80",
+	"V_VP9":            "VP90",
+	"V_AV1":            "av01",
+	"V_MPEG4/ISO/ASP":  "mp4v",
+	"V_MPEG2":          "mp2v",
+	"V_THEORA":         "theo",
+}
+
+// element EBML 元素
+type element struct {
+	id   uint32
+	size uint64
+	data []byte
+}
+
+// readVint 解析 EBML 变长整数，keepMarker 为 true 时保留长度标记位（用于元素 ID）
+func readVint(data []byte, keepMarker bool) (uint64, int, bool) {
+	if len(data) == 0 || data[0] == 0 {
+		return 0, 0, false
+	}
+	length := 1
+	for mask := byte(0x80); data[0]&mask == 0; mask >>= 1 {
+		length++
+	}
+	if length > 8 || len(data) < length {
+		return 0, 0, false
+	}
+
+	value := uint64(data[0])
+	if !keepMarker {
+		value &= uint64(0xFF >> length)
+	}
+	allOnes := value == uint64(0xFF>>length)
+	for i := 1; i < length; i++ {
+		value = value<<8 | uint64(data[i])
+		allOnes = allOnes && data[i] == 0xFF
+	}
+	if !keepMarker && allOnes {
+		return unknownSize, length, true
+	}
+	return value, length, true
+}
+
+// elements 解析 data 中连续的子元素
+func elements(data []byte) []element {
+	var result []element
+	for len(data) > 0 {
+		id, n, ok := readVint(data, true)
+		if !ok {
+			return result
+		}
+		size, m, ok := readVint(data[n:], false)
+		if !ok {
+			return result
+		}
+		data = data[n+m:]
+		if size == unknownSize || size > uint64(len(data)) {
+			size = uint64(len(data))
+		}
+		result = append(result, element{id: uint32(id), size: size, data: data[:size]})
+		data = data[size:]
+	}
+	return result
+}
+
+// readHeader 读取 offset 处元素的 ID 与长度，返回头部长度
+func readHeader(r io.ReaderAt, offset int64) (uint32, uint64, int, error) {
+	buf := make([]byte, 16)
+	n, err := r.ReadAt(buf, offset)
+	if n == 0 && err != nil {
+		return 0, 0, 0, err
+	}
+	buf = buf[:n]
+	id, idLen, ok := readVint(buf, true)
+	if !ok {
+		return 0, 0, 0, ErrUnsupported
+	}
+	size, sizeLen, ok := readVint(buf[idLen:], false)
+	if !ok {
+		return 0, 0, 0, ErrUnsupported
+	}
+	return uint32(id), size, idLen + sizeLen, nil
+}
+
+// decodeMatroska 读取 Segment 中的 Info 与 Tracks，遇到 Cluster 即停止
+func decodeMatroska(r io.ReaderAt, size int64) (*Meta, error) {
+	id, ebmlSize, headerLen, err := readHeader(r, 0)
+	if err != nil || id != idEBML {
+		return nil, ErrUnsupported
+	}
+	offset := int64(headerLen) + int64(ebmlSize)
+
+	id, segmentSize, headerLen, err := readHeader(r, offset)
+	if err != nil || id != idSegment {
+		return nil, ErrUnsupported
+	}
+	offset += int64(headerLen)
+	end := size
+	if segmentSize != unknownSize && offset+int64(segmentSize) < size {
+		end = offset + int64(segmentSize)
+	}
+
+	meta := &Meta{}
+	var info, tracks []byte
+	for offset < end && (info == nil || tracks == nil) {
+		id, elemSize, headerLen, err := readHeader(r, offset)
+		if err != nil {
+			break
+		}
+		offset += int64(headerLen)
+		if id == idCluster || elemSize == unknownSize {
+			break
+		}
+		if id == idInfo || id == idTracks {
+			if elemSize > maxHeaderSize {
+				return nil, fmt.Errorf("元素过大：%d", elemSize)
+			}
+			data := make([]byte, elemSize)
+			if _, err := r.ReadAt(data, offset); err != nil {
+				return nil, fmt.Errorf("读取元素失败：%w", err)
+			}
+			if id == idInfo {
+				info = data
+			} else {
+				tracks = data
+			}
+		}
+		offset += int64(elemSize)
+	}
+	if info == nil && tracks == nil {
+		return nil, ErrUnsupported
+	}
+
+	parseInfo(info, meta)
+	parseTracks(tracks, meta)
+	return meta, nil
+}
+
+// parseInfo 解析 Segment Info 中的时长与创建时间
+func parseInfo(info []byte, meta *Meta) {
+	timecodeScale := uint64(1000000)
+	var duration float64
+	for _, e := range elements(info) {
+		switch e.id {
+		case idTimecodeScale:
+			if v := readUint(e.data); v > 0 {
+				timecodeScale = v
+			}
+		case idDuration:
+			duration = readFloat(e.data)
+		case idDateUTC:
+			if len(e.data) == 8 {
+				nanos := int64(binary.BigEndian.Uint64(e.data))
+				meta.CreationTime = matroskaEpoch.Add(time.Duration(nanos))
+			}
+		}
+	}
+	meta.Duration = duration * float64(timecodeScale) / float64(time.Second)
+}
+
+// parseTracks 解析第一条视频轨道
+func parseTracks(tracks []byte, meta *Meta) {
+	for _, track := range elements(tracks) {
+		if track.id != idTrackEntry {
+			continue
+		}
+		fields := elements(track.data)
+		if readUint(find(fields, idTrackType)) != trackTypeVideo {
+			continue
+		}
+
+		codecID := strings.TrimRight(string(find(fields, idCodecID)), "\x00")
+		if fourCC, ok := matroskaCodecs[codecID]; ok {
+			meta.Codec = fourCC
+		} else {
+			meta.Codec = codecID
+		}
+		if frameDuration := readUint(find(fields, idDefaultDuration)); frameDuration > 0 {
+			meta.FrameRate = float64(time.Second) / float64(frameDuration)
+		}
+		videoFields := elements(find(fields, idVideo))
+		meta.Width = int(readUint(find(videoFields, idPixelWidth)))
+		meta.Height = int(readUint(find(videoFields, idPixelHeight)))
+		return
+	}
+}
+
+// find 返回第一个指定 ID 的元素内容
+func find(fields []element, id uint32) []byte {
+	for _, e := range fields {
+		if e.id == id {
+			return e.data
+		}
+	}
+	return nil
+}
+
+// readUint 解析大端无符号整数
+func readUint(data []byte) uint64 {
+	var v uint64
+	for _, b := range data {
+		v = v<<8 | uint64(b)
+	}
+	return v
+}
+
+// readFloat 解析 4 或 8 字节浮点数
+func readFloat(data []byte) float64 {
+	switch len(data) {
+	case 4:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(data)))
+	case 8:
+		return math.Float64frombits(binary.BigEndian.Uint64(data))
+	}
+	return 0
+}
@@ -0,0 +1,58 @@
+// Package video 纯 Go 实现的视频容器元数据读取，支持 MP4/MOV（ISO BMFF）与 Matroska/WebM
+package video
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// ErrUnsupported 不支持的视频容器格式
+var ErrUnsupported = errors.New("不支持的视频格式")
+
+// maxHeaderSize 读入内存解析的头部数据上限，防止损坏文件导致过量读取
+const maxHeaderSize = 64 << 20
+
+// Meta 视频元数据，缺失的字段为零值
+type Meta struct {
+	Duration     float64   `json:"duration,omitempty"`     // 时长（秒）
+	Width        int       `json:"width,omitempty"`        // 像素宽度
+	Height       int       `json:"height,omitempty"`       // 像素高度
+	FrameRate    float64   `json:"frameRate,omitempty"`    // 帧率
+	Codec        string    `json:"codec,omitempty"`        // 视频编码 FourCC，如 avc1、hvc1
+	CreationTime time.Time `json:"creationTime,omitempty"` // 创建时间
+}
+
+// ReadFile 读取 path 视频的元数据
+func ReadFile(path string) (*Meta, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开文件失败：%w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("读取文件信息失败：%w", err)
+	}
+	return Decode(f, info.Size())
+}
+
+// Decode 从 r 中读取视频元数据，size 为数据总长度
+func Decode(r io.ReaderAt, size int64) (*Meta, error) {
+	magic := make([]byte, 8)
+	if _, err := r.ReadAt(magic, 0); err != nil {
+		return nil, fmt.Errorf("读取文件头失败：%w", err)
+	}
+
+	switch {
+	case bytes.Equal(magic[:4], ebmlMagic):
+		return decodeMatroska(r, size)
+	case isBMFF(magic[4:8]):
+		return decodeBMFF(r, size)
+	}
+	return nil, ErrUnsupported
+}
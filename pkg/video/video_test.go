@@ -0,0 +1,117 @@
+package video
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mp4Box 构造 ISO BMFF box
+func mp4Box(typ string, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+	buf := binary.BigEndian.AppendUint32(nil, uint32(8+len(body)))
+	buf = append(buf, typ...)
+	return append(buf, body...)
+}
+
+// u32 大端 32 位整数
+func u32(values ...uint32) []byte {
+	var buf []byte
+	for _, v := range values {
+		buf = binary.BigEndian.AppendUint32(buf, v)
+	}
+	return buf
+}
+
+// buildMP4 构造 10 秒、1920x1080、30fps 的 MP4，moov 位于 mdat 之后
+func buildMP4(created time.Time) []byte {
+	seconds := uint32(created.Sub(bmffEpoch) / time.Second)
+	mvhd := mp4Box("mvhd", u32(0, seconds, seconds, 1000, 10000), make([]byte, 80))
+
+	tkhd := append(u32(0), make([]byte, 72)...)
+	tkhd = append(tkhd, u32(1920<<16, 1080<<16)...)
+	hdlr := mp4Box("hdlr", u32(0, 0), []byte("vide"), make([]byte, 12))
+	mdhd := mp4Box("mdhd", u32(0, seconds, seconds, 30000, 300000), make([]byte, 4))
+	entry := mp4Box("avc1", make([]byte, 24), []byte{0x07, 0x80, 0x04, 0x38}, make([]byte, 50))
+	stsd := mp4Box("stsd", u32(0, 1), entry)
+	stts := mp4Box("stts", u32(0, 2, 200, 1000, 100, 1000))
+	stbl := mp4Box("stbl", stsd, stts)
+	trak := mp4Box("trak", mp4Box("tkhd", tkhd), mp4Box("mdia", mdhd, hdlr, mp4Box("minf", stbl)))
+
+	// 音频轨道在前，应被跳过
+	soun := mp4Box("trak", mp4Box("mdia", mp4Box("hdlr", u32(0, 0), []byte("soun"), make([]byte, 12))))
+	moov := mp4Box("moov", mvhd, soun, trak)
+	return bytes.Join([][]byte{
+		mp4Box("ftyp", []byte("isom"), u32(0x200), []byte("isomavc1")),
+		mp4Box("mdat", make([]byte, 1024)),
+		moov,
+	}, nil)
+}
+
+// ebml 构造 EBML 元素，id 为带标记位的元素 ID
+func ebml(id uint32, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+	var buf []byte
+	for shift := 24; shift >= 0; shift -= 8 {
+		if b := byte(id >> shift); b != 0 || len(buf) > 0 {
+			buf = append(buf, b)
+		}
+	}
+	// 长度统一使用 8 字节编码
+	size := binary.BigEndian.AppendUint64(nil, uint64(len(body)))
+	size[0] = 0x01
+	buf = append(buf, size...)
+	return append(buf, body...)
+}
+
+// buildWebM 构造 12.5 秒、1280x720、25fps 的 WebM
+func buildWebM(created time.Time) []byte {
+	duration := binary.BigEndian.AppendUint64(nil, math.Float64bits(12500))
+	date := binary.BigEndian.AppendUint64(nil, uint64(created.Sub(matroskaEpoch)))
+	info := ebml(idInfo,
+		ebml(idTimecodeScale, []byte{0x0F, 0x42, 0x40}),
+		ebml(idDuration, duration),
+		ebml(idDateUTC, date),
+	)
+	audio := ebml(idTrackEntry, ebml(idTrackType, []byte{2}), ebml(idCodecID, []byte("A_OPUS")))
+	video := ebml(idTrackEntry,
+		ebml(idTrackType, []byte{1}),
+		ebml(idCodecID, []byte("V_VP9")),
+		ebml(idDefaultDuration, u32(40000000)),
+		ebml(idVideo, ebml(idPixelWidth, []byte{0x05, 0x00}), ebml(idPixelHeight, []byte{0x02, 0xD0})),
+	)
+	segment := ebml(idSegment, info, ebml(idTracks, audio, video), ebml(idCluster, make([]byte, 64)))
+	return append(ebml(idEBML, ebml(0x4282, []byte("webm"))), segment...)
+}
+
+func TestDecode(t *testing.T) {
+	created := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+
+	data := buildMP4(created)
+	meta, err := Decode(bytes.NewReader(data), int64(len(data)))
+	if assert.Nil(t, err) {
+		assert.InDelta(t, 10, meta.Duration, 1e-9)
+		assert.Equal(t, []int{1920, 1080}, []int{meta.Width, meta.Height})
+		assert.InDelta(t, 30, meta.FrameRate, 1e-9)
+		assert.Equal(t, "avc1", meta.Codec)
+		assert.True(t, created.Equal(meta.CreationTime))
+	}
+
+	data = buildWebM(created)
+	meta, err = Decode(bytes.NewReader(data), int64(len(data)))
+	if assert.Nil(t, err) {
+		assert.InDelta(t, 12.5, meta.Duration, 1e-9)
+		assert.Equal(t, []int{1280, 720}, []int{meta.Width, meta.Height})
+		assert.InDelta(t, 25, meta.FrameRate, 1e-9)
+		assert.Equal(t, "VP90", meta.Codec)
+		assert.True(t, created.Equal(meta.CreationTime))
+	}
+
+	data = []byte("RIFF\x00\x00\x00\x00AVI LIST")
+	_, err = Decode(bytes.NewReader(data), int64(len(data)))
+	assert.ErrorIs(t, err, ErrUnsupported)
+}
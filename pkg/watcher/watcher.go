@@ -0,0 +1,260 @@
+// Package watcher 监听目录变化，优先使用系统通知（fsnotify），不可用时退化为轮询
+package watcher
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"media-app/pkg/logger"
+
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+)
+
+// Options 监听选项
+type Options struct {
+	Recursive    bool                   // 是否监听子目录
+	SkipDir      func(path string) bool // 返回 true 时不监听该子目录
+	Debounce     time.Duration          // 合并连续变化的静默时间
+	PollInterval time.Duration          // 轮询模式的扫描间隔
+	ForcePolling bool                   // 强制使用轮询模式
+}
+
+// DefaultOptions 返回默认监听选项
+func DefaultOptions() Options {
+	return Options{
+		Debounce:     500 * time.Millisecond,
+		PollInterval: 3 * time.Second,
+	}
+}
+
+// Watcher 目录监听器，目录内容变化且静默 Debounce 后调用 onChange
+type Watcher struct {
+	root     string
+	options  Options
+	onChange func()
+
+	fsWatcher *fsnotify.Watcher
+	done      chan struct{}
+	closeOnce sync.Once
+
+	timerMux sync.Mutex
+	timer    *time.Timer
+}
+
+// New 创建并启动目录监听器
+func New(root string, options Options, onChange func()) (*Watcher, error) {
+	stat, err := os.Stat(root)
+	if err != nil {
+		return nil, fmt.Errorf("目录不存在或无法访问：%w", err)
+	}
+	if !stat.IsDir() {
+		return nil, fmt.Errorf("指定路径不是文件夹：%s", root)
+	}
+	if options.Debounce <= 0 {
+		options.Debounce = DefaultOptions().Debounce
+	}
+	if options.PollInterval <= 0 {
+		options.PollInterval = DefaultOptions().PollInterval
+	}
+
+	w := &Watcher{
+		root:     root,
+		options:  options,
+		onChange: onChange,
+		done:     make(chan struct{}),
+	}
+
+	if !options.ForcePolling {
+		err := w.startNotify()
+		if err == nil {
+			return w, nil
+		}
+		logger.Warn("系统文件通知不可用，使用轮询监听", zap.String("dir", root), zap.Error(err))
+	}
+	go w.poll(w.snapshot())
+	return w, nil
+}
+
+// Polling 是否处于轮询模式
+func (w *Watcher) Polling() bool {
+	return w.fsWatcher == nil
+}
+
+// Close 停止监听
+func (w *Watcher) Close() {
+	w.closeOnce.Do(func() {
+		close(w.done)
+		if w.fsWatcher != nil {
+			_ = w.fsWatcher.Close()
+		}
+		w.timerMux.Lock()
+		if w.timer != nil {
+			w.timer.Stop()
+		}
+		w.timerMux.Unlock()
+	})
+}
+
+// startNotify 使用 fsnotify 监听根目录及子目录
+func (w *Watcher) startNotify() error {
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	w.fsWatcher = fsWatcher
+	if err := w.addDirs(w.root); err != nil {
+		_ = fsWatcher.Close()
+		w.fsWatcher = nil
+		return err
+	}
+	go w.loop()
+	return nil
+}
+
+// addDirs 将 dir 及需要监听的子目录加入 fsnotify
+func (w *Watcher) addDirs(dir string) error {
+	if !w.options.Recursive {
+		return w.fsWatcher.Add(dir)
+	}
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir {
+				return err
+			}
+			return nil
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != w.root && w.skip(path) {
+			return filepath.SkipDir
+		}
+		return w.fsWatcher.Add(path)
+	})
+}
+
+// skip 子目录是否跳过
+func (w *Watcher) skip(path string) bool {
+	return w.options.SkipDir != nil && w.options.SkipDir(path)
+}
+
+// loop 处理 fsnotify 事件
+func (w *Watcher) loop() {
+	for {
+		select {
+		case <-w.done:
+			return
+		case event, ok := <-w.fsWatcher.Events:
+			if !ok {
+				return
+			}
+			// 新建的子目录需要加入监听
+			if w.options.Recursive && event.Has(fsnotify.Create) {
+				if stat, err := os.Stat(event.Name); err == nil && stat.IsDir() && !w.skip(event.Name) {
+					if err := w.addDirs(event.Name); err != nil {
+						logger.Error("监听新目录失败", zap.String("dir", event.Name), zap.Error(err))
+					}
+				}
+			}
+			if event.Has(fsnotify.Chmod) && !event.Has(fsnotify.Write) {
+				continue
+			}
+			w.trigger()
+		case err, ok := <-w.fsWatcher.Errors:
+			if !ok {
+				return
+			}
+			logger.Error("目录监听错误", zap.String("dir", w.root), zap.Error(err))
+		}
+	}
+}
+
+// fileState 轮询模式下记录的文件状态
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// poll 定时扫描目录，对比前后两次的文件状态
+func (w *Watcher) poll(last map[string]fileState) {
+	ticker := time.NewTicker(w.options.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+			current := w.snapshot()
+			if !sameSnapshot(last, current) {
+				w.trigger()
+			}
+			last = current
+		}
+	}
+}
+
+// snapshot 记录目录下所有文件的大小与修改时间
+func (w *Watcher) snapshot() map[string]fileState {
+	states := make(map[string]fileState)
+	_ = filepath.WalkDir(w.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != w.root && (!w.options.Recursive || w.skip(path)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		states[path] = fileState{size: info.Size(), modTime: info.ModTime()}
+		return nil
+	})
+	return states
+}
+
+// sameSnapshot 两次快照是否一致
+func sameSnapshot(a, b map[string]fileState) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for path, state := range a {
+		other, ok := b[path]
+		if !ok || other.size != state.size || !other.modTime.Equal(state.modTime) {
+			return false
+		}
+	}
+	return true
+}
+
+// trigger 重置静默计时，计时结束后回调 onChange
+func (w *Watcher) trigger() {
+	w.timerMux.Lock()
+	defer w.timerMux.Unlock()
+
+	select {
+	case <-w.done:
+		return
+	default:
+	}
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.timer = time.AfterFunc(w.options.Debounce, func() {
+		select {
+		case <-w.done:
+			return
+		default:
+		}
+		w.onChange()
+	})
+}
@@ -0,0 +1,60 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"media-app/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	cfg := logger.DefaultConfig()
+	cfg.FileName = filepath.Join(os.TempDir(), "media-app-test", "app.log")
+	cfg.OutputConsole = false
+	if err := logger.Init(cfg); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+func TestWatcher(t *testing.T) {
+	for _, polling := range []bool{false, true} {
+		root := t.TempDir()
+		assert.Nil(t, os.MkdirAll(filepath.Join(root, "sub"), 0755))
+		assert.Nil(t, os.MkdirAll(filepath.Join(root, ".delete"), 0755))
+
+		changes := make(chan struct{}, 10)
+		w, err := New(root, Options{
+			Recursive:    true,
+			SkipDir:      func(path string) bool { return filepath.Base(path) == ".delete" },
+			Debounce:     50 * time.Millisecond,
+			PollInterval: 50 * time.Millisecond,
+			ForcePolling: polling,
+		}, func() { changes <- struct{}{} })
+		assert.Nil(t, err)
+		assert.Equal(t, polling, w.Polling())
+
+		// 连续多次变化只回调一次
+		for _, name := range []string{"a.jpg", "sub/b.jpg", "sub/c.jpg"} {
+			assert.Nil(t, os.WriteFile(filepath.Join(root, name), []byte(name), 0644))
+		}
+		select {
+		case <-changes:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("polling=%v: 未收到目录变化", polling)
+		}
+		time.Sleep(200 * time.Millisecond)
+		assert.Len(t, changes, 0)
+
+		// 跳过的目录不触发
+		assert.Nil(t, os.WriteFile(filepath.Join(root, ".delete", "d.jpg"), []byte("d"), 0644))
+		time.Sleep(300 * time.Millisecond)
+		assert.Len(t, changes, 0)
+
+		w.Close()
+	}
+}